@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"house-design-backend/database"
+	"house-design-backend/mdimport"
+)
+
+// runCommand runs a maintenance command given on the command line in place of the server,
+// e.g. `go run . sanitize-posts -dry-run` or `go run . import-markdown -category nha-pho articles/`
+func runCommand(args []string) {
+	switch args[0] {
+	case "sanitize-posts":
+		flags := flag.NewFlagSet("sanitize-posts", flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "report what would be stripped without saving")
+		flags.Parse(args[1:])
+
+		posts, err := database.SanitizeAllPosts(*dryRun)
+		for _, post := range posts {
+			log.Printf("Post %d %q: removed %s", post.ID, post.Title,
+				describeCounts(post.Report.RemovedElements, post.Report.RemovedAttributes))
+		}
+		if err != nil {
+			log.Fatal("Failed to sanitize posts: ", err)
+		}
+		if *dryRun {
+			log.Println("Dry run, nothing was saved")
+		}
+	case "import-markdown":
+		flags := flag.NewFlagSet("import-markdown", flag.ExitOnError)
+		category := flags.String("category", "", "category slug for files whose front matter has none")
+		flags.Parse(args[1:])
+		if flags.NArg() == 0 {
+			log.Fatal("Usage: import-markdown [-category slug] file.md|directory...")
+		}
+
+		failed := false
+		for _, file := range markdownFiles(flags.Args()) {
+			source, err := os.ReadFile(file)
+			if err != nil {
+				log.Printf("%s: %v", file, err)
+				failed = true
+				continue
+			}
+			// Local images are relative to the Markdown file
+			dir := filepath.Dir(file)
+			result := mdimport.Import(file, source, func(path string) ([]byte, error) {
+				return os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+			}, mdimport.Options{DefaultCategory: *category, CreateTags: true})
+
+			if result.Status == "failed" {
+				log.Printf("%s: failed: %s", file, result.Error)
+				failed = true
+				continue
+			}
+			log.Printf("%s: %s post %d (%s), %d images", file, result.Status, result.PostID, result.Slug, len(result.Images))
+			if result.ContentSanitized != nil {
+				log.Printf("%s: removed %s", file, describeCounts(result.ContentSanitized.RemovedElements,
+					result.ContentSanitized.RemovedAttributes))
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	default:
+		log.Fatalf("Unknown command %q, available commands: sanitize-posts, import-markdown", args[0])
+	}
+}
+
+// markdownFiles expands the directories among paths to the .md files inside them
+func markdownFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && strings.EqualFold(filepath.Ext(file), ".md") {
+				files = append(files, file)
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// describeCounts lists the keys of count maps with their counts, e.g. "script x2, a[href]"
+func describeCounts(counts ...map[string]int) string {
+	var parts []string
+	for _, m := range counts {
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if m[key] > 1 {
+				key += " x" + strconv.Itoa(m[key])
+			}
+			parts = append(parts, key)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
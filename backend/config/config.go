@@ -3,7 +3,9 @@ package config
 import (
 	"bufio"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // LoadEnv loads environment variables from .env file
@@ -38,3 +40,45 @@ func LoadEnv() error {
 
 	return scanner.Err()
 }
+
+// GetDuration reads a time.Duration (e.g. "15m", "720h") from the environment,
+// falling back to defaultValue when the variable is unset or invalid
+func GetDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return defaultValue
+	}
+	return duration
+}
+
+// GetInt reads a positive integer from the environment, falling back to defaultValue
+// when the variable is unset or invalid
+func GetInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// GetList reads a comma-separated list from the environment, dropping blank entries and
+// falling back to defaultValue when the variable is unset or empty
+func GetList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"house-design-backend/models"
+
+	"github.com/lib/pq"
+)
+
+// createAPIKeysTable creates the store for hashed API keys
+func createAPIKeysTable() {
+	tables := []string{
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			key_prefix VARCHAR(20) NOT NULL,
+			key_hash VARCHAR(64) NOT NULL UNIQUE,
+			scopes TEXT[] NOT NULL DEFAULT '{}',
+			created_by INTEGER NOT NULL REFERENCES admin(id) ON DELETE CASCADE,
+			expires_at TIMESTAMP,
+			last_used_at TIMESTAMP,
+			last_used_ip VARCHAR(64),
+			revoked_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+	}
+
+	for _, table := range tables {
+		if _, err := DB.Exec(table); err != nil {
+			log.Fatal("Failed to create API keys table:", err)
+		}
+	}
+
+	log.Println("API keys table created successfully")
+}
+
+const apiKeyColumns = `k.id, k.name, k.key_prefix, k.scopes, k.created_by, a.username, k.expires_at,
+	k.last_used_at, COALESCE(k.last_used_ip, ''), k.revoked_at, k.created_at`
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }, key *models.APIKey) error {
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedBy, &key.CreatedByUsername,
+		&expiresAt, &lastUsedAt, &key.LastUsedIP, &revokedAt, &key.CreatedAt)
+	if err != nil {
+		return err
+	}
+	key.ExpiresAt = nullTimePtr(expiresAt)
+	key.LastUsedAt = nullTimePtr(lastUsedAt)
+	key.RevokedAt = nullTimePtr(revokedAt)
+	return nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// CreateAPIKey stores a new API key by hash and returns it
+func CreateAPIKey(name, prefix, keyHash string, scopes []string, createdBy uint, expiresAt *time.Time) (*models.APIKey, error) {
+	var id uint
+	err := DB.QueryRow(`INSERT INTO api_keys (name, key_prefix, key_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		name, prefix, keyHash, pq.Array(scopes), createdBy, expiresAt).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.APIKey{}
+	row := DB.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys k JOIN admin a ON a.id = k.created_by WHERE k.id = $1", id)
+	if err := scanAPIKey(row, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns all API keys, newest first. Revoked keys are only included when includeRevoked is set.
+func ListAPIKeys(includeRevoked bool) ([]models.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys k JOIN admin a ON a.id = k.created_by"
+	if !includeRevoked {
+		query += " WHERE k.revoked_at IS NULL"
+	}
+	query += " ORDER BY k.created_at DESC, k.id DESC"
+
+	rows, err := DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var key models.APIKey
+		if err := scanAPIKey(rows, &key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revokes a key and reports whether a live key was found
+func RevokeAPIKey(id int) (bool, error) {
+	result, err := DB.Exec("UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// TouchAPIKey records the last use of a key. Writes are limited to one per minute per key.
+func TouchAPIKey(id uint, ipAddress string) error {
+	_, err := DB.Exec(`UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP, last_used_ip = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute' OR last_used_ip IS DISTINCT FROM $2)`,
+		id, ipAddress)
+	return err
+}
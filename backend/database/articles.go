@@ -0,0 +1,145 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"house-design-backend/slug"
+)
+
+// legacyArticle is a row of the articles table, which no handler ever served
+type legacyArticle struct {
+	id                                            int
+	title, content, summary, imageURL             string
+	categoryID                                    int
+	published                                     bool
+	tags, metaTitle, metaDescription, articleSlug string
+	focusKeywords, ogImageURL, canonicalURL       string
+	authorID                                      sql.NullInt64
+	views                                         int
+	createdAt, updatedAt                          sql.NullTime
+}
+
+// foldArticlesTable copies the rows of the old articles table into posts, turning their
+// comma-separated tags into tags, and drops the table. It does nothing once the table is
+// gone. When any row fails the fold rolls back entirely and startup stops with the
+// article at fault, rather than running on with the articles table left behind. It must
+// run after createPostRevisionsTable, whose columns have to fit every copied article.
+func foldArticlesTable() {
+	var exists bool
+	if err := DB.QueryRow("SELECT to_regclass('articles') IS NOT NULL").Scan(&exists); err != nil {
+		log.Fatal("Failed to check for the articles table:", err)
+	}
+	if !exists {
+		return
+	}
+
+	moved, err := moveArticlesToPosts()
+	if err != nil {
+		log.Fatal("Failed to fold articles into posts, fix the article and restart: ", err)
+	}
+
+	log.Printf("Articles table folded into posts (%d articles copied) and dropped", moved)
+}
+
+func moveArticlesToPosts() (int, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Older databases may predate the columns migrateArticlesTable used to add
+	for _, column := range []string{"focus_keywords TEXT", "og_image_url VARCHAR(500)", "canonical_url VARCHAR(500)"} {
+		if _, err := tx.Exec("ALTER TABLE articles ADD COLUMN IF NOT EXISTS " + column); err != nil {
+			return 0, err
+		}
+	}
+
+	rows, err := tx.Query(`SELECT id, title, content, COALESCE(summary, ''), COALESCE(featured_image_url, ''), category_id,
+		COALESCE(published, FALSE), COALESCE(tags, ''), COALESCE(meta_title, ''), COALESCE(meta_description, ''), slug,
+		COALESCE(focus_keywords, ''), COALESCE(og_image_url, ''), COALESCE(canonical_url, ''), author_id,
+		COALESCE(view_count, 0), created_at, updated_at
+		FROM articles ORDER BY id`)
+	if err != nil {
+		return 0, err
+	}
+	var articles []legacyArticle
+	for rows.Next() {
+		var a legacyArticle
+		if err := rows.Scan(&a.id, &a.title, &a.content, &a.summary, &a.imageURL, &a.categoryID, &a.published,
+			&a.tags, &a.metaTitle, &a.metaDescription, &a.articleSlug, &a.focusKeywords, &a.ogImageURL, &a.canonicalURL,
+			&a.authorID, &a.views, &a.createdAt, &a.updatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		articles = append(articles, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, a := range articles {
+		// Keep the article's slug unless a post already uses it
+		base := slug.Make(a.articleSlug)
+		if base == "" {
+			base = slug.Make(a.title)
+		}
+		if base == "" {
+			base = "article"
+		}
+		postSlug := base
+		for counter := 2; ; counter++ {
+			var taken bool
+			if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM posts WHERE slug = $1)", postSlug).Scan(&taken); err != nil {
+				return 0, err
+			}
+			if !taken {
+				break
+			}
+			postSlug = slug.WithSuffix(base, counter)
+		}
+
+		var postID uint
+		err := tx.QueryRow(`INSERT INTO posts (title, content, summary, image_url, category_id, published, views,
+				meta_title, meta_description, focus_keywords, og_image_url, canonical_url, slug, author_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+				COALESCE($15, CURRENT_TIMESTAMP), COALESCE($16, CURRENT_TIMESTAMP))
+			RETURNING id`,
+			a.title, a.content, a.summary, a.imageURL, a.categoryID, a.published, a.views,
+			a.metaTitle, a.metaDescription, a.focusKeywords, a.ogImageURL, a.canonicalURL, postSlug, a.authorID,
+			a.createdAt, a.updatedAt).Scan(&postID)
+		if err != nil {
+			return 0, fmt.Errorf("article %d: %w", a.id, err)
+		}
+
+		for _, name := range strings.Split(a.tags, ",") {
+			name = strings.TrimSpace(name)
+			tagSlug := slug.Make(name)
+			if tagSlug == "" {
+				continue
+			}
+			if _, err := tx.Exec(`WITH tag AS (
+					INSERT INTO tags (name, slug) VALUES ($1, $2)
+					ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+					RETURNING id
+				)
+				INSERT INTO post_tags (post_id, tag_id) SELECT $3, id FROM tag ON CONFLICT DO NOTHING`,
+				name, tagSlug, postID); err != nil {
+				return 0, fmt.Errorf("article %d tag %q: %w", a.id, name, err)
+			}
+		}
+
+		if err := SavePostRevision(tx, postID, uint(a.authorID.Int64), 0); err != nil {
+			return 0, fmt.Errorf("article %d revision: %w", a.id, err)
+		}
+	}
+
+	if _, err := tx.Exec("DROP TABLE articles"); err != nil {
+		return 0, err
+	}
+	return len(articles), tx.Commit()
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"house-design-backend/models"
+	"house-design-backend/slug"
 
 	_ "github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
@@ -40,9 +41,18 @@ func InitDatabase() {
 
 	log.Println("Connected to PostgreSQL database successfully")
 	createTables()
+	migrateAdminTable()
+	createSessionsTable()
+	createLoginSecurityTables()
+	createTwoFactorTables()
+	createPasswordResetTable()
+	createAPIKeysTable()
 	migrateCategoriesTable()
 	migratePostsTable()
-	migrateArticlesTable()
+	setupPostSearch()
+	createPostRevisionsTable()
+	createTagsTables()
+	foldArticlesTable()
 	migrateHomeContentTable()
 	createFooterContentTable()
 	migrateFooterContentTable()
@@ -84,28 +94,7 @@ func createTables() {
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	// Create articles table (enhanced posts for rich text content)
-	articlesTable := `
-	CREATE TABLE IF NOT EXISTS articles (
-		id SERIAL PRIMARY KEY,
-		title VARCHAR(500) NOT NULL,
-		content TEXT NOT NULL,
-		summary TEXT,
-		featured_image_url VARCHAR(500),
-		category_id INTEGER NOT NULL,
-		published BOOLEAN DEFAULT FALSE,
-		tags VARCHAR(1000),
-		meta_title VARCHAR(255),
-		meta_description TEXT,
-		slug VARCHAR(500) UNIQUE NOT NULL,
-		author_id INTEGER REFERENCES admin(id),
-		view_count INTEGER DEFAULT 0,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
-	)`
-
-	// Keep existing posts table for backward compatibility
+	// Create posts table
 	postsTable := `
 	CREATE TABLE IF NOT EXISTS posts (
 		id SERIAL PRIMARY KEY,
@@ -134,7 +123,7 @@ func createTables() {
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	)`
 
-	tables := []string{adminTable, categoriesTable, articlesTable, postsTable, homeContentTable}
+	tables := []string{adminTable, categoriesTable, postsTable, homeContentTable}
 
 	for _, table := range tables {
 		if _, err := DB.Exec(table); err != nil {
@@ -148,6 +137,39 @@ func createTables() {
 	createGlobalSEOSettingsTable()
 }
 
+func migrateAdminTable() {
+	// Add user management columns to the admin table
+	migrations := []string{
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS email VARCHAR(255)",
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS display_name VARCHAR(255)",
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS avatar_url VARCHAR(500)",
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS bio TEXT",
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS role VARCHAR(50)",
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS is_active BOOLEAN DEFAULT TRUE",
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP",
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP",
+		// TOTP two-factor authentication
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64)",
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN DEFAULT FALSE",
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS totp_last_counter BIGINT DEFAULT 0",
+		// Password management
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN DEFAULT FALSE",
+		"ALTER TABLE admin ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP",
+		// Accounts that existed before roles were introduced had full access, keep it that way
+		"UPDATE admin SET role = 'owner' WHERE role IS NULL",
+		"ALTER TABLE admin ALTER COLUMN role SET DEFAULT 'editor'",
+		"ALTER TABLE admin ALTER COLUMN role SET NOT NULL",
+	}
+
+	for _, migration := range migrations {
+		if _, err := DB.Exec(migration); err != nil {
+			log.Printf("Admin migration warning: %v", err)
+		}
+	}
+
+	log.Println("Admin table migration completed")
+}
+
 func migrateCategoriesTable() {
 	// Check if the new columns exist, and add them if they don't
 	migrations := []string{
@@ -163,6 +185,11 @@ func migrateCategoriesTable() {
 		"ALTER TABLE categories ADD COLUMN IF NOT EXISTS meta_description TEXT",
 		"ALTER TABLE categories ADD COLUMN IF NOT EXISTS meta_keywords TEXT",
 		"ALTER TABLE categories ADD COLUMN IF NOT EXISTS og_image_url VARCHAR(500)",
+		// Optimistic concurrency: incremented by every edit, compared with If-Match
+		"ALTER TABLE categories ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1",
+		// Trash: deleted categories keep their row until PurgeTrash removes them
+		"ALTER TABLE categories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ",
+		"CREATE INDEX IF NOT EXISTS idx_categories_deleted_at ON categories(deleted_at) WHERE deleted_at IS NOT NULL",
 	}
 
 	for _, migration := range migrations {
@@ -194,6 +221,22 @@ func migratePostsTable() {
 		"ALTER TABLE posts ADD COLUMN IF NOT EXISTS focus_keywords TEXT",
 		"ALTER TABLE posts ADD COLUMN IF NOT EXISTS og_image_url VARCHAR(500)",
 		"ALTER TABLE posts ADD COLUMN IF NOT EXISTS slug VARCHAR(255)",
+		// Fields that used to live only in the articles table
+		"ALTER TABLE posts ADD COLUMN IF NOT EXISTS canonical_url VARCHAR(500)",
+		"ALTER TABLE posts ADD COLUMN IF NOT EXISTS author_id INTEGER REFERENCES admin(id) ON DELETE SET NULL",
+		"ALTER TABLE posts ADD COLUMN IF NOT EXISTS last_edited_by INTEGER REFERENCES admin(id) ON DELETE SET NULL",
+		"CREATE INDEX IF NOT EXISTS idx_posts_author_id ON posts(author_id)",
+		// Scheduled publishing, applied by ApplyPostSchedule. TIMESTAMPTZ keeps the instant
+		// of times sent with a UTC offset, e.g. 2025-01-01T08:00:00+07:00
+		"ALTER TABLE posts ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ",
+		"ALTER TABLE posts ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMPTZ",
+		"CREATE INDEX IF NOT EXISTS idx_posts_publish_at ON posts(publish_at) WHERE publish_at IS NOT NULL",
+		"CREATE INDEX IF NOT EXISTS idx_posts_unpublish_at ON posts(unpublish_at) WHERE unpublish_at IS NOT NULL",
+		// Optimistic concurrency: incremented by every edit, compared with If-Match
+		"ALTER TABLE posts ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1",
+		// Trash: deleted posts keep their row until PurgeTrash removes them
+		"ALTER TABLE posts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ",
+		"CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts(deleted_at) WHERE deleted_at IS NOT NULL",
 	}
 
 	for _, migration := range migrations {
@@ -202,24 +245,70 @@ func migratePostsTable() {
 		}
 	}
 
+	// Post URLs and the Markdown import rely on unique slugs, so running without the
+	// index is not an option
+	if err := uniquePostSlugs(); err != nil {
+		log.Fatal("Failed to make post slugs unique, fix the duplicate slugs and restart: ", err)
+	}
+
 	log.Println("Posts table migration completed")
 }
 
-func migrateArticlesTable() {
-	// Add missing SEO fields to articles table
-	migrations := []string{
-		"ALTER TABLE articles ADD COLUMN IF NOT EXISTS focus_keywords TEXT",
-		"ALTER TABLE articles ADD COLUMN IF NOT EXISTS og_image_url VARCHAR(500)",
-		"ALTER TABLE articles ADD COLUMN IF NOT EXISTS canonical_url VARCHAR(500)",
+// uniquePostSlugs fixes up posts saved before slugs were enforced and adds the unique index.
+// Empty slugs get a placeholder; duplicates other than the oldest post get the post ID as a
+// suffix, or the first free counter after it, shortened to fit like any other slug.
+func uniquePostSlugs() error {
+	if _, err := DB.Exec("UPDATE posts SET slug = 'post-' || id WHERE slug IS NULL OR slug = ''"); err != nil {
+		return err
 	}
 
-	for _, migration := range migrations {
-		if _, err := DB.Exec(migration); err != nil {
-			log.Printf("Migration warning: %v", err)
+	rows, err := DB.Query(`SELECT id, slug FROM posts p
+		WHERE EXISTS (SELECT 1 FROM posts older WHERE older.slug = p.slug AND older.id < p.id)
+		ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	type duplicate struct {
+		id   int
+		slug string
+	}
+	var duplicates []duplicate
+	for rows.Next() {
+		var d duplicate
+		if err := rows.Scan(&d.id, &d.slug); err != nil {
+			rows.Close()
+			return err
 		}
+		duplicates = append(duplicates, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
-	log.Println("Articles table migration completed")
+	for _, d := range duplicates {
+		base := slug.WithSuffix(d.slug, d.id)
+		candidate := base
+		for counter := 2; ; counter++ {
+			var taken bool
+			if err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM posts WHERE slug = $1)", candidate).Scan(&taken); err != nil {
+				return err
+			}
+			if !taken {
+				break
+			}
+			candidate = slug.WithSuffix(base, counter)
+		}
+		if _, err := DB.Exec("UPDATE posts SET slug = $2 WHERE id = $1", d.id, candidate); err != nil {
+			return fmt.Errorf("post %d: %w", d.id, err)
+		}
+	}
+
+	if _, err := DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_slug ON posts(slug)"); err != nil {
+		return err
+	}
+	_, err = DB.Exec("ALTER TABLE posts ALTER COLUMN slug SET NOT NULL")
+	return err
 }
 
 func migrateHomeContentTable() {
@@ -337,18 +426,19 @@ func seedAdminUser() {
 	}
 
 	if count == 0 {
-		// Create default admin user (password: admin123)
-		// In production, use proper password hashing
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.DefaultCost)
+		// Create default admin user (password: admin123), which must be changed on first login
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(DefaultAdminPassword), bcrypt.DefaultCost)
 		if err != nil {
 			log.Fatal("Failed to hash password:", err)
 		}
 
-		_, err = DB.Exec("INSERT INTO admin (username, password) VALUES ($1, $2)", "admin", string(hashedPassword))
+		_, err = DB.Exec("INSERT INTO admin (username, password, role, must_change_password) VALUES ($1, $2, $3, TRUE)", "admin", string(hashedPassword), models.RoleOwner)
 		if err != nil {
 			log.Fatal("Failed to create admin user:", err)
 		}
 		log.Println("Default admin user created (username: admin, password: admin123)")
+	} else {
+		flagDefaultAdminPassword()
 	}
 	// Seed some default categories and home content
 	seedDefaultCategories()
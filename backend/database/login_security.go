@@ -0,0 +1,182 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"house-design-backend/config"
+	"house-design-backend/models"
+
+	"github.com/lib/pq"
+)
+
+// createLoginSecurityTables creates the login audit log and the failed-attempt counters
+func createLoginSecurityTables() {
+	tables := []string{
+		`CREATE TABLE IF NOT EXISTS login_attempts (
+			id SERIAL PRIMARY KEY,
+			username VARCHAR(255) NOT NULL,
+			ip_address VARCHAR(64),
+			user_agent TEXT,
+			success BOOLEAN NOT NULL,
+			reason VARCHAR(50),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		// One row per "user:<username>" or "ip:<address>" key
+		`CREATE TABLE IF NOT EXISTS login_throttle (
+			key VARCHAR(320) PRIMARY KEY,
+			failures INTEGER NOT NULL DEFAULT 0,
+			last_failure_at TIMESTAMP NOT NULL,
+			locked_until TIMESTAMP
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_login_attempts_created_at ON login_attempts(created_at)",
+		"CREATE INDEX IF NOT EXISTS idx_login_attempts_username ON login_attempts(username)",
+	}
+
+	for _, table := range tables {
+		if _, err := DB.Exec(table); err != nil {
+			log.Fatal("Failed to create login security tables:", err)
+		}
+	}
+
+	log.Println("Login security tables created successfully")
+}
+
+// LoginAttemptRetention is how long entries stay in the login audit log, configurable with
+// LOGIN_ATTEMPT_RETENTION
+func LoginAttemptRetention() time.Duration {
+	return config.GetDuration("LOGIN_ATTEMPT_RETENTION", 90*24*time.Hour)
+}
+
+// PurgeLoginAttempts deletes the audit log entries older than LoginAttemptRetention, along
+// with failure counters that have been idle as long and are not locked
+func PurgeLoginAttempts() error {
+	cutoff := fmt.Sprintf("%d seconds", int(LoginAttemptRetention().Seconds()))
+
+	attempts, err := DB.Exec("DELETE FROM login_attempts WHERE created_at < NOW() - $1::INTERVAL", cutoff)
+	if err != nil {
+		return err
+	}
+	throttles, err := DB.Exec(`DELETE FROM login_throttle WHERE last_failure_at < NOW() - $1::INTERVAL
+		AND (locked_until IS NULL OR locked_until < NOW())`, cutoff)
+	if err != nil {
+		return err
+	}
+
+	purgedAttempts, _ := attempts.RowsAffected()
+	purgedThrottles, _ := throttles.RowsAffected()
+	if purgedAttempts > 0 || purgedThrottles > 0 {
+		log.Printf("Login audit purged: %d attempts, %d idle counters", purgedAttempts, purgedThrottles)
+	}
+	return nil
+}
+
+// RecordLoginAttempt appends an entry to the login audit log
+func RecordLoginAttempt(username, ipAddress, userAgent string, success bool, reason string) error {
+	_, err := DB.Exec(`INSERT INTO login_attempts (username, ip_address, user_agent, success, reason)
+		VALUES ($1, $2, $3, $4, $5)`, username, ipAddress, userAgent, success, reason)
+	return err
+}
+
+// GetLockedUntil returns the latest lock expiry among keys, or the zero time if none is locked
+func GetLockedUntil(keys ...string) (time.Time, error) {
+	var lockedUntil sql.NullTime
+	err := DB.QueryRow("SELECT MAX(locked_until) FROM login_throttle WHERE key = ANY($1) AND locked_until > NOW()",
+		pq.Array(keys)).Scan(&lockedUntil)
+	if err != nil || !lockedUntil.Valid {
+		return time.Time{}, err
+	}
+	return lockedUntil.Time, nil
+}
+
+// RegisterLoginFailure increments the failure counter of key and returns the new count.
+// Counters whose last failure is older than window start again from one.
+func RegisterLoginFailure(key string, window time.Duration) (int, error) {
+	var failures int
+	err := DB.QueryRow(`INSERT INTO login_throttle (key, failures, last_failure_at) VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_throttle.last_failure_at < NOW() - $2::INTERVAL THEN 1 ELSE login_throttle.failures + 1 END,
+			last_failure_at = NOW()
+		RETURNING failures`, key, fmt.Sprintf("%d seconds", int(window.Seconds()))).Scan(&failures)
+	return failures, err
+}
+
+// LockLogin blocks key until lockedUntil
+func LockLogin(key string, lockedUntil time.Time) error {
+	_, err := DB.Exec("UPDATE login_throttle SET locked_until = $2 WHERE key = $1", key, lockedUntil)
+	return err
+}
+
+// ResetLoginFailures clears the counters and locks of the given keys and returns how many were cleared
+func ResetLoginFailures(keys ...string) (int64, error) {
+	result, err := DB.Exec("DELETE FROM login_throttle WHERE key = ANY($1)", pq.Array(keys))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListLoginLockouts returns the keys that are currently locked
+func ListLoginLockouts() ([]models.LoginLockout, error) {
+	rows, err := DB.Query(`SELECT key, failures, last_failure_at, locked_until FROM login_throttle
+		WHERE locked_until > NOW() ORDER BY locked_until DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lockouts := []models.LoginLockout{}
+	for rows.Next() {
+		var lockout models.LoginLockout
+		if err := rows.Scan(&lockout.Key, &lockout.Failures, &lockout.LastFailureAt, &lockout.LockedUntil); err != nil {
+			return nil, err
+		}
+		lockouts = append(lockouts, lockout)
+	}
+	return lockouts, rows.Err()
+}
+
+// ListLoginAttempts returns the most recent login attempts, optionally filtered
+func ListLoginAttempts(username, ipAddress string, onlyFailures bool, limit int) ([]models.LoginAttempt, error) {
+	var conditions []string
+	var args []interface{}
+	if username != "" {
+		args = append(args, username)
+		conditions = append(conditions, fmt.Sprintf("LOWER(username) = LOWER($%d)", len(args)))
+	}
+	if ipAddress != "" {
+		args = append(args, ipAddress)
+		conditions = append(conditions, fmt.Sprintf("ip_address = $%d", len(args)))
+	}
+	if onlyFailures {
+		conditions = append(conditions, "success = FALSE")
+	}
+
+	query := `SELECT id, username, COALESCE(ip_address, ''), COALESCE(user_agent, ''), success, COALESCE(reason, ''), created_at
+		FROM login_attempts`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []models.LoginAttempt{}
+	for rows.Next() {
+		var attempt models.LoginAttempt
+		if err := rows.Scan(&attempt.ID, &attempt.Username, &attempt.IPAddress, &attempt.UserAgent,
+			&attempt.Success, &attempt.Reason, &attempt.CreatedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultAdminPassword is the password of the account created by seedAdminUser
+const DefaultAdminPassword = "admin123"
+
+// createPasswordResetTable creates the store for single-use password reset tokens
+func createPasswordResetTable() {
+	tables := []string{
+		`CREATE TABLE IF NOT EXISTS password_reset_tokens (
+			id SERIAL PRIMARY KEY,
+			admin_id INTEGER NOT NULL REFERENCES admin(id) ON DELETE CASCADE,
+			token_hash VARCHAR(64) NOT NULL UNIQUE,
+			ip_address VARCHAR(64),
+			expires_at TIMESTAMP NOT NULL,
+			used_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_admin_id ON password_reset_tokens(admin_id)",
+	}
+
+	for _, table := range tables {
+		if _, err := DB.Exec(table); err != nil {
+			log.Fatal("Failed to create password reset table:", err)
+		}
+	}
+
+	log.Println("Password reset table created successfully")
+}
+
+// flagDefaultAdminPassword forces a password change on the seeded account while it still
+// uses the default password, including databases created before the flag existed
+func flagDefaultAdminPassword() {
+	var id uint
+	var hash string
+	err := DB.QueryRow("SELECT id, password FROM admin WHERE username = 'admin' AND NOT COALESCE(must_change_password, FALSE)").Scan(&id, &hash)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Failed to check default admin password: %v", err)
+		}
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(DefaultAdminPassword)) != nil {
+		return
+	}
+	if _, err := DB.Exec("UPDATE admin SET must_change_password = TRUE WHERE id = $1", id); err != nil {
+		log.Printf("Failed to flag default admin password: %v", err)
+		return
+	}
+	log.Println("Warning: the admin account still uses the default password, it must be changed on next login")
+}
+
+// CreatePasswordResetToken stores the hash of a new reset token and invalidates the admin's
+// earlier unused tokens, so only the most recent email link works
+func CreatePasswordResetToken(adminID uint, tokenHash, ipAddress string, expiresAt time.Time) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP WHERE admin_id = $1 AND used_at IS NULL",
+		adminID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO password_reset_tokens (admin_id, token_hash, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4)`, adminID, tokenHash, ipAddress, expiresAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConsumePasswordResetToken marks a valid reset token as used and returns its admin.
+// It returns sql.ErrNoRows when the token is unknown, expired or already used.
+func ConsumePasswordResetToken(tx *sql.Tx, tokenHash string) (uint, error) {
+	var adminID uint
+	err := tx.QueryRow(`UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING admin_id`, tokenHash).Scan(&adminID)
+	return adminID, err
+}
+
+// SetAdminPassword stores a new bcrypt hash and clears the forced-change flag
+func SetAdminPassword(exec Execer, adminID uint, hash string) error {
+	_, err := exec.Exec(`UPDATE admin SET password = $2, must_change_password = FALSE, password_changed_at = CURRENT_TIMESTAMP,
+		updated_at = CURRENT_TIMESTAMP WHERE id = $1`, adminID, hash)
+	return err
+}
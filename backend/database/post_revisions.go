@@ -0,0 +1,80 @@
+package database
+
+import (
+	"log"
+
+	"house-design-backend/config"
+)
+
+// PostRevisionLimit is how many revisions are kept per post, configurable with POST_REVISION_LIMIT
+func PostRevisionLimit() int {
+	return config.GetInt("POST_REVISION_LIMIT", 50)
+}
+
+// createPostRevisionsTable creates the history of saved post versions
+func createPostRevisionsTable() {
+	tables := []string{
+		`CREATE TABLE IF NOT EXISTS post_revisions (
+			id SERIAL PRIMARY KEY,
+			post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+			revision INTEGER NOT NULL,
+			title VARCHAR(500) NOT NULL,
+			summary TEXT NOT NULL DEFAULT '',
+			content TEXT NOT NULL DEFAULT '',
+			image_url VARCHAR(500) NOT NULL DEFAULT '',
+			meta_title VARCHAR(255) NOT NULL DEFAULT '',
+			meta_description TEXT NOT NULL DEFAULT '',
+			focus_keywords TEXT NOT NULL DEFAULT '',
+			og_image_url VARCHAR(500) NOT NULL DEFAULT '',
+			editor_id INTEGER REFERENCES admin(id) ON DELETE SET NULL,
+			restored_from INTEGER,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (post_id, revision)
+		)`,
+		// Revision columns must be as wide as the posts columns they copy; databases created
+		// before title matched posts.title still have VARCHAR(255)
+		`ALTER TABLE post_revisions ALTER COLUMN title TYPE VARCHAR(500)`,
+	}
+
+	for _, table := range tables {
+		if _, err := DB.Exec(table); err != nil {
+			log.Fatal("Failed to create post revisions table:", err)
+		}
+	}
+
+	log.Println("Post revisions table created successfully")
+}
+
+// SavePostRevision snapshots the current state of a post as its next revision. editorID
+// is 0 when unknown, restoredFrom the revision number a restore copied, 0 otherwise.
+// Callers must hold the post's row lock so that revision numbers do not collide.
+func SavePostRevision(exec Execer, postID, editorID uint, restoredFrom int) error {
+	_, err := exec.Exec(`INSERT INTO post_revisions (post_id, revision, title, summary, content, image_url,
+			meta_title, meta_description, focus_keywords, og_image_url, editor_id, restored_from)
+		SELECT p.id, COALESCE((SELECT MAX(revision) FROM post_revisions WHERE post_id = p.id), 0) + 1,
+			p.title, COALESCE(p.summary, ''), COALESCE(p.content, ''), COALESCE(p.image_url, ''),
+			COALESCE(p.meta_title, ''), COALESCE(p.meta_description, ''), COALESCE(p.focus_keywords, ''),
+			COALESCE(p.og_image_url, ''), NULLIF($2, 0), NULLIF($3, 0)
+		FROM posts p WHERE p.id = $1`, postID, editorID, restoredFrom)
+	return err
+}
+
+// SaveBaselinePostRevision records the current state of a post that has no revisions yet,
+// typically one written before revisions existed, so its first update keeps the old text
+func SaveBaselinePostRevision(exec Execer, postID uint) error {
+	_, err := exec.Exec(`INSERT INTO post_revisions (post_id, revision, title, summary, content, image_url,
+			meta_title, meta_description, focus_keywords, og_image_url, created_at)
+		SELECT p.id, 1, p.title, COALESCE(p.summary, ''), COALESCE(p.content, ''), COALESCE(p.image_url, ''),
+			COALESCE(p.meta_title, ''), COALESCE(p.meta_description, ''), COALESCE(p.focus_keywords, ''),
+			COALESCE(p.og_image_url, ''), COALESCE(p.updated_at, p.created_at, CURRENT_TIMESTAMP)
+		FROM posts p
+		WHERE p.id = $1 AND NOT EXISTS (SELECT 1 FROM post_revisions WHERE post_id = p.id)`, postID)
+	return err
+}
+
+// PrunePostRevisions keeps only the newest keep revisions of a post
+func PrunePostRevisions(exec Execer, postID uint, keep int) error {
+	_, err := exec.Exec(`DELETE FROM post_revisions WHERE post_id = $1 AND revision <=
+		(SELECT MAX(revision) FROM post_revisions WHERE post_id = $1) - $2`, postID, keep)
+	return err
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+
+	"house-design-backend/sanitize"
+)
+
+// SanitizePostContent applies the sanitize policy to the stored content of a post and
+// writes it back when anything was removed. Callers must hold the post's row lock.
+func SanitizePostContent(tx *sql.Tx, postID uint) (sanitize.Report, error) {
+	var content string
+	if err := tx.QueryRow("SELECT COALESCE(content, '') FROM posts WHERE id = $1", postID).Scan(&content); err != nil {
+		return sanitize.Report{}, err
+	}
+
+	clean, report := sanitize.HTML(content)
+	if report.Empty() {
+		return report, nil
+	}
+	_, err := tx.Exec("UPDATE posts SET content = $2 WHERE id = $1", postID, clean)
+	return report, err
+}
+
+// SanitizedPost is a post whose content SanitizeAllPosts stripped something from
+type SanitizedPost struct {
+	ID     uint
+	Title  string
+	Report sanitize.Report
+}
+
+// SanitizeAllPosts applies the sanitize policy to the content of every post, trashed ones
+// included, in one transaction per post. A post that changes gets a new version and a
+// revision with no editor. With dryRun the changes are reported but rolled back.
+func SanitizeAllPosts(dryRun bool) ([]SanitizedPost, error) {
+	rows, err := DB.Query("SELECT id FROM posts ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var sanitized []SanitizedPost
+	for _, id := range ids {
+		post, err := sanitizeStoredPost(id, dryRun)
+		if err != nil {
+			return sanitized, err
+		}
+		if post != nil {
+			sanitized = append(sanitized, *post)
+		}
+	}
+
+	log.Printf("Sanitized %d of %d posts", len(sanitized), len(ids))
+	return sanitized, nil
+}
+
+// sanitizeStoredPost sanitizes one post for SanitizeAllPosts, returning nil when nothing
+// was stripped or the post no longer exists
+func sanitizeStoredPost(postID uint, dryRun bool) (*SanitizedPost, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	post := SanitizedPost{ID: postID}
+	err = tx.QueryRow("SELECT title FROM posts WHERE id = $1 FOR UPDATE", postID).Scan(&post.Title)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Keeps the unsanitized content in the history of posts saved before revisions existed
+	if err := SaveBaselinePostRevision(tx, postID); err != nil {
+		return nil, err
+	}
+
+	if post.Report, err = SanitizePostContent(tx, postID); err != nil || post.Report.Empty() {
+		return nil, err
+	}
+	if dryRun {
+		return &post, nil
+	}
+
+	if _, err := tx.Exec("UPDATE posts SET version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $1", postID); err != nil {
+		return nil, err
+	}
+	if err := SavePostRevision(tx, postID, 0, 0); err != nil {
+		return nil, err
+	}
+	if err := PrunePostRevisions(tx, postID, PostRevisionLimit()); err != nil {
+		return nil, err
+	}
+	return &post, tx.Commit()
+}
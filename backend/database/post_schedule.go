@@ -0,0 +1,50 @@
+package database
+
+import (
+	"log"
+)
+
+// Advisory lock keys for jobs that must run on one server instance at a time
+const (
+	postScheduleLockKey int64 = 7301001
+)
+
+// ApplyPostSchedule publishes posts whose publish_at has passed and unpublishes posts
+// whose unpublish_at has passed, clearing the applied timestamp so that a later manual
+// change is not overridden. When another instance holds the lock the call does nothing.
+func ApplyPostSchedule() error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRow("SELECT pg_try_advisory_xact_lock($1)", postScheduleLockKey).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+
+	// Publishing runs first so a window that has already closed ends unpublished
+	published, err := tx.Exec("UPDATE posts SET published = TRUE, publish_at = NULL, version = version + 1 WHERE publish_at <= NOW() AND deleted_at IS NULL")
+	if err != nil {
+		return err
+	}
+	unpublished, err := tx.Exec("UPDATE posts SET published = FALSE, unpublish_at = NULL, version = version + 1 WHERE unpublish_at <= NOW() AND deleted_at IS NULL")
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	publishedCount, _ := published.RowsAffected()
+	unpublishedCount, _ := unpublished.RowsAffected()
+	if publishedCount > 0 || unpublishedCount > 0 {
+		log.Printf("Post schedule applied: %d published, %d unpublished", publishedCount, unpublishedCount)
+	}
+	return nil
+}
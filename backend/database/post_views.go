@@ -0,0 +1,21 @@
+package database
+
+import (
+	"github.com/lib/pq"
+)
+
+// AddPostViews adds buffered view counts, by post ID, to posts.views in one statement.
+// Counts for posts deleted in the meantime are dropped.
+func AddPostViews(counts map[uint]int) error {
+	ids := make([]int64, 0, len(counts))
+	views := make([]int64, 0, len(counts))
+	for postID, count := range counts {
+		ids = append(ids, int64(postID))
+		views = append(views, int64(count))
+	}
+
+	_, err := DB.Exec(`UPDATE posts p SET views = COALESCE(p.views, 0) + v.count
+		FROM UNNEST($1::INTEGER[], $2::INTEGER[]) AS v(id, count)
+		WHERE p.id = v.id`, pq.Array(ids), pq.Array(views))
+	return err
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"log"
+)
+
+// SearchConfig is the text search configuration used for post search. With the unaccent
+// extension it folds diacritics, so "biet thu" matches "biệt thự".
+const SearchConfig = "vietnamese_unaccent"
+
+// setupPostSearch creates the accent-folding search configuration and the indexed
+// search_vector column on posts. PostgreSQL keeps the column up to date on every write.
+func setupPostSearch() {
+	unaccent := true
+	if _, err := DB.Exec("CREATE EXTENSION IF NOT EXISTS unaccent"); err != nil {
+		unaccent = false
+		log.Printf("Search warning: unaccent extension is unavailable, search will be accent-sensitive: %v", err)
+	}
+
+	mapping := ""
+	if unaccent {
+		mapping = "ALTER TEXT SEARCH CONFIGURATION " + SearchConfig + " ALTER MAPPING FOR hword, hword_part, word WITH unaccent, simple;"
+	}
+
+	statements := []string{
+		`DO $$ BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = '` + SearchConfig + `') THEN
+				CREATE TEXT SEARCH CONFIGURATION ` + SearchConfig + ` (COPY = simple);
+				` + mapping + `
+			END IF;
+		END $$`,
+		// Plain text of the HTML post body: tags and entities become spaces
+		`CREATE OR REPLACE FUNCTION html_to_text(html TEXT) RETURNS TEXT
+			LANGUAGE sql IMMUTABLE AS $$
+			SELECT regexp_replace(regexp_replace(COALESCE(html, ''), '<[^>]*>', ' ', 'g'), '&(#[0-9]+|#x[0-9a-fA-F]+|[a-zA-Z]+);', ' ', 'g')
+		$$`,
+		`ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+			setweight(to_tsvector('` + SearchConfig + `', COALESCE(title, '')), 'A') ||
+			setweight(to_tsvector('` + SearchConfig + `', COALESCE(focus_keywords, '')), 'A') ||
+			setweight(to_tsvector('` + SearchConfig + `', COALESCE(summary, '')), 'B') ||
+			setweight(to_tsvector('` + SearchConfig + `', html_to_text(content)), 'C')
+		) STORED`,
+		"CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector)",
+	}
+
+	for _, statement := range statements {
+		if _, err := DB.Exec(statement); err != nil {
+			log.Printf("Search migration warning: %v", err)
+		}
+	}
+
+	log.Println("Post search setup completed")
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"house-design-backend/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrSessionNotFound is returned when a refresh token does not match any live session
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again.
+	// The session is revoked because the token has most likely been stolen.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// Execer is satisfied by both *sql.DB and *sql.Tx
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Queryer is satisfied by both *sql.DB and *sql.Tx
+type Queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// createSessionsTable creates the sessions table that backs refresh tokens and revocation
+func createSessionsTable() {
+	sessionsTable := `
+	CREATE TABLE IF NOT EXISTS sessions (
+		id VARCHAR(36) PRIMARY KEY,
+		admin_id INTEGER NOT NULL REFERENCES admin(id) ON DELETE CASCADE,
+		refresh_token_hash VARCHAR(64) UNIQUE NOT NULL,
+		previous_token_hash VARCHAR(64),
+		ip_address VARCHAR(64),
+		user_agent TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		expires_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP
+	)`
+
+	if _, err := DB.Exec(sessionsTable); err != nil {
+		log.Fatal("Failed to create sessions table:", err)
+	}
+
+	indexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_sessions_admin_id ON sessions(admin_id)",
+		"CREATE INDEX IF NOT EXISTS idx_sessions_previous_token_hash ON sessions(previous_token_hash)",
+	}
+	for _, index := range indexes {
+		if _, err := DB.Exec(index); err != nil {
+			log.Printf("Sessions index warning: %v", err)
+		}
+	}
+
+	log.Println("Sessions table created successfully")
+}
+
+// CreateSession stores a new session and returns its ID
+func CreateSession(adminID uint, refreshTokenHash, ipAddress, userAgent string, expiresAt time.Time) (string, error) {
+	id := uuid.New().String()
+	_, err := DB.Exec(`INSERT INTO sessions (id, admin_id, refresh_token_hash, ip_address, user_agent, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, adminID, refreshTokenHash, ipAddress, userAgent, expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	// Opportunistically drop sessions that ended a while ago
+	if _, err := DB.Exec("DELETE FROM sessions WHERE expires_at < NOW() - INTERVAL '7 days' OR revoked_at < NOW() - INTERVAL '7 days'"); err != nil {
+		log.Printf("Failed to clean up old sessions: %v", err)
+	}
+
+	return id, nil
+}
+
+// RotateSession swaps the refresh token of the session that owns oldHash for newHash
+// and returns the session. Presenting a token that was already rotated revokes the session.
+func RotateSession(oldHash, newHash, ipAddress, userAgent string, expiresAt time.Time) (*models.Session, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	session := &models.Session{}
+	var revokedAt sql.NullTime
+	err = tx.QueryRow(`SELECT id, admin_id, expires_at, revoked_at FROM sessions
+		WHERE refresh_token_hash = $1 FOR UPDATE`, oldHash).Scan(&session.ID, &session.AdminID, &session.ExpiresAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		// An old token from a rotated session means someone else holds the current one
+		var sessionID string
+		err = tx.QueryRow("SELECT id FROM sessions WHERE previous_token_hash = $1", oldHash).Scan(&sessionID)
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL", sessionID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if revokedAt.Valid || session.ExpiresAt.Before(time.Now()) {
+		return nil, ErrSessionNotFound
+	}
+
+	err = tx.QueryRow(`UPDATE sessions SET previous_token_hash = refresh_token_hash, refresh_token_hash = $2,
+		ip_address = $3, user_agent = $4, last_used_at = CURRENT_TIMESTAMP, expires_at = $5
+		WHERE id = $1 RETURNING ip_address, user_agent, created_at, last_used_at, expires_at`,
+		session.ID, newHash, ipAddress, userAgent, expiresAt).Scan(
+		&session.IPAddress, &session.UserAgent, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ListActiveSessions returns the live sessions of an admin, most recently used first
+func ListActiveSessions(adminID uint) ([]models.Session, error) {
+	rows, err := DB.Query(`SELECT id, admin_id, COALESCE(ip_address, ''), COALESCE(user_agent, ''), created_at, last_used_at, expires_at
+		FROM sessions WHERE admin_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC`, adminID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(&session.ID, &session.AdminID, &session.IPAddress, &session.UserAgent,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession revokes one session of an admin. It returns false if no live session matched.
+func RevokeSession(sessionID string, adminID uint) (bool, error) {
+	result, err := DB.Exec("UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND admin_id = $2 AND revoked_at IS NULL",
+		sessionID, adminID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// RevokeSessionByRefreshToken revokes the session that currently owns refreshTokenHash
+func RevokeSessionByRefreshToken(refreshTokenHash string) error {
+	_, err := DB.Exec("UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE refresh_token_hash = $1 AND revoked_at IS NULL",
+		refreshTokenHash)
+	return err
+}
+
+// RevokeAllSessions revokes every live session of an admin and returns how many were revoked
+func RevokeAllSessions(exec Execer, adminID uint) (int64, error) {
+	result, err := exec.Exec("UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE admin_id = $1 AND revoked_at IS NULL", adminID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// RevokeOtherSessions revokes every live session of an admin except keepSessionID
+func RevokeOtherSessions(exec Execer, adminID uint, keepSessionID string) (int64, error) {
+	result, err := exec.Exec("UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE admin_id = $1 AND id <> $2 AND revoked_at IS NULL",
+		adminID, keepSessionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
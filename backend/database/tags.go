@@ -0,0 +1,54 @@
+package database
+
+import (
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// createTagsTables creates the tags and the join table assigning them to posts
+func createTagsTables() {
+	tables := []string{
+		`CREATE TABLE IF NOT EXISTS tags (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			slug VARCHAR(255) NOT NULL UNIQUE,
+			description TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_tags (
+			post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+			tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+			PRIMARY KEY (post_id, tag_id)
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_post_tags_tag_id ON post_tags(tag_id)",
+	}
+
+	for _, table := range tables {
+		if _, err := DB.Exec(table); err != nil {
+			log.Fatal("Failed to create tags tables:", err)
+		}
+	}
+
+	log.Println("Tags tables created successfully")
+}
+
+// SetPostTags replaces the tags of a post. Unknown tag IDs are ignored.
+func SetPostTags(exec Execer, postID uint, tagIDs []uint) error {
+	if _, err := exec.Exec("DELETE FROM post_tags WHERE post_id = $1", postID); err != nil {
+		return err
+	}
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(tagIDs))
+	for i, id := range tagIDs {
+		ids[i] = int64(id)
+	}
+	_, err := exec.Exec(`INSERT INTO post_tags (post_id, tag_id)
+		SELECT $1, id FROM tags WHERE id = ANY($2::INTEGER[])
+		ON CONFLICT DO NOTHING`, postID, pq.Array(ids))
+	return err
+}
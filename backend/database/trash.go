@@ -0,0 +1,47 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"house-design-backend/config"
+)
+
+// TrashRetention is how long deleted posts and categories stay in the trash before they are
+// purged, configurable with TRASH_RETENTION
+func TrashRetention() time.Duration {
+	return config.GetDuration("TRASH_RETENTION", 30*24*time.Hour)
+}
+
+// PurgeTrash permanently deletes the posts and categories that have been in the trash longer
+// than TrashRetention. A category is only purged once no post or subcategory refers to it, so
+// a purge never takes live content with it; nested categories go one level per pass.
+func PurgeTrash() error {
+	cutoff := time.Now().Add(-TrashRetention())
+
+	posts, err := DB.Exec("DELETE FROM posts WHERE deleted_at < $1", cutoff)
+	if err != nil {
+		return err
+	}
+	purgedPosts, _ := posts.RowsAffected()
+
+	var purgedCategories int64
+	for {
+		categories, err := DB.Exec(`DELETE FROM categories c WHERE c.deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM posts p WHERE p.category_id = c.id)
+			AND NOT EXISTS (SELECT 1 FROM categories child WHERE child.parent_id = c.id)`, cutoff)
+		if err != nil {
+			return err
+		}
+		purged, _ := categories.RowsAffected()
+		if purged == 0 {
+			break
+		}
+		purgedCategories += purged
+	}
+
+	if purgedPosts > 0 || purgedCategories > 0 {
+		log.Printf("Trash purged: %d posts, %d categories", purgedPosts, purgedCategories)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+
+	"house-design-backend/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// createTwoFactorTables creates the recovery code store and the security settings singleton
+func createTwoFactorTables() {
+	tables := []string{
+		`CREATE TABLE IF NOT EXISTS admin_recovery_codes (
+			id SERIAL PRIMARY KEY,
+			admin_id INTEGER NOT NULL REFERENCES admin(id) ON DELETE CASCADE,
+			code_hash VARCHAR(255) NOT NULL,
+			used_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_admin_recovery_codes_admin_id ON admin_recovery_codes(admin_id)",
+		`CREATE TABLE IF NOT EXISTS security_settings (
+			id SERIAL PRIMARY KEY,
+			require_two_factor BOOLEAN NOT NULL DEFAULT FALSE,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+	}
+
+	for _, table := range tables {
+		if _, err := DB.Exec(table); err != nil {
+			log.Fatal("Failed to create two-factor tables:", err)
+		}
+	}
+
+	var count int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM security_settings").Scan(&count); err != nil {
+		log.Fatal("Failed to check security settings:", err)
+	}
+	if count == 0 {
+		if _, err := DB.Exec("INSERT INTO security_settings (require_two_factor) VALUES (FALSE)"); err != nil {
+			log.Printf("Failed to seed security settings: %v", err)
+		}
+	}
+
+	log.Println("Two-factor tables created successfully")
+}
+
+// GetSecuritySettings returns the site-wide authentication policy
+func GetSecuritySettings() (*models.SecuritySettings, error) {
+	settings := &models.SecuritySettings{}
+	err := DB.QueryRow("SELECT require_two_factor, updated_at FROM security_settings ORDER BY id LIMIT 1").Scan(
+		&settings.RequireTwoFactor, &settings.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// UpdateSecuritySettings stores the site-wide authentication policy
+func UpdateSecuritySettings(settings *models.SecuritySettings) error {
+	return DB.QueryRow(`UPDATE security_settings SET require_two_factor = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = (SELECT id FROM security_settings ORDER BY id LIMIT 1) RETURNING updated_at`,
+		settings.RequireTwoFactor).Scan(&settings.UpdatedAt)
+}
+
+// ReplaceRecoveryCodes discards the admin's recovery codes and stores bcrypt hashes of the new ones
+func ReplaceRecoveryCodes(tx *sql.Tx, adminID uint, codes []string) error {
+	if _, err := tx.Exec("DELETE FROM admin_recovery_codes WHERE admin_id = $1", adminID); err != nil {
+		return err
+	}
+	for _, code := range codes {
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO admin_recovery_codes (admin_id, code_hash) VALUES ($1, $2)", adminID, string(hash)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode marks the matching unused recovery code as used. It returns false if none matched.
+func UseRecoveryCode(adminID uint, code string) (bool, error) {
+	rows, err := DB.Query("SELECT id, code_hash FROM admin_recovery_codes WHERE admin_id = $1 AND used_at IS NULL", adminID)
+	if err != nil {
+		return false, err
+	}
+
+	var matchedID int
+	for rows.Next() {
+		var id int
+		var hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			rows.Close()
+			return false, err
+		}
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(code)) == nil {
+			matchedID = id
+			break
+		}
+	}
+	rows.Close()
+	if matchedID == 0 {
+		return false, nil
+	}
+
+	// The used_at check makes a concurrent second use of the same code fail
+	result, err := DB.Exec("UPDATE admin_recovery_codes SET used_at = CURRENT_TIMESTAMP WHERE id = $1 AND used_at IS NULL", matchedID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes the admin has left
+func CountUnusedRecoveryCodes(adminID uint) (int, error) {
+	var count int
+	err := DB.QueryRow("SELECT COUNT(*) FROM admin_recovery_codes WHERE admin_id = $1 AND used_at IS NULL", adminID).Scan(&count)
+	return count, err
+}
+
+// DisableTwoFactor removes the TOTP secret and recovery codes of an admin. It reports
+// false when there is no such admin.
+func DisableTwoFactor(tx *sql.Tx, adminID uint) (bool, error) {
+	result, err := tx.Exec(`UPDATE admin SET totp_secret = NULL, totp_enabled = FALSE, totp_last_counter = 0,
+		updated_at = CURRENT_TIMESTAMP WHERE id = $1`, adminID)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return false, nil
+	}
+	_, err = tx.Exec("DELETE FROM admin_recovery_codes WHERE admin_id = $1", adminID)
+	return err == nil, err
+}
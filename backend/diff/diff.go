@@ -0,0 +1,107 @@
+package diff
+
+import (
+	"strings"
+)
+
+// Operation kinds of a Chunk
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// maxCells bounds the LCS table; larger inputs are reported as one delete and one insert
+const maxCells = 4_000_000
+
+// Chunk is a run of consecutive lines that are kept, added or removed
+type Chunk struct {
+	Op    string   `json:"op"`
+	Lines []string `json:"lines"`
+}
+
+// Lines compares two texts line by line and returns the chunks turning a into b
+func Lines(a, b string) []Chunk {
+	return Slices(splitLines(a), splitLines(b))
+}
+
+// Slices compares two lists of lines and returns the chunks turning a into b
+func Slices(a, b []string) []Chunk {
+	// Common prefix and suffix do not need the quadratic table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var chunks []Chunk
+	chunks = appendChunk(chunks, Equal, a[:prefix]...)
+	chunks = append(chunks, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	chunks = appendChunk(chunks, Equal, a[len(a)-suffix:]...)
+	return chunks
+}
+
+// middle diffs the part of a and b between their common prefix and suffix
+func middle(a, b []string) []Chunk {
+	if len(a)*len(b) > maxCells {
+		var chunks []Chunk
+		chunks = appendChunk(chunks, Delete, a...)
+		return appendChunk(chunks, Insert, b...)
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var chunks []Chunk
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			chunks = appendChunk(chunks, Equal, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			chunks = appendChunk(chunks, Delete, a[i])
+			i++
+		default:
+			chunks = appendChunk(chunks, Insert, b[j])
+			j++
+		}
+	}
+	chunks = appendChunk(chunks, Delete, a[i:]...)
+	return appendChunk(chunks, Insert, b[j:]...)
+}
+
+// appendChunk adds lines to the last chunk when it has the same operation
+func appendChunk(chunks []Chunk, op string, lines ...string) []Chunk {
+	if len(lines) == 0 {
+		return chunks
+	}
+	if n := len(chunks); n > 0 && chunks[n-1].Op == op {
+		chunks[n-1].Lines = append(chunks[n-1].Lines, lines...)
+		return chunks
+	}
+	return append(chunks, Chunk{Op: op, Lines: append([]string(nil), lines...)})
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"house-design-backend/database"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// postAuthorColumns and postAuthorJoin add the author byline to post queries aliasing posts as p
+const (
+	postAuthorColumns = `p.author_id, p.last_edited_by, COALESCE(au.display_name, ''), COALESCE(au.avatar_url, ''), COALESCE(au.bio, '')`
+	postAuthorJoin    = ` LEFT JOIN admin au ON au.id = p.author_id`
+)
+
+// postAuthorDest returns the scan destinations for postAuthorColumns; call setPostAuthor after scanning
+func postAuthorDest(post *models.Post, author *models.Author) []interface{} {
+	return []interface{}{&post.AuthorID, &post.LastEditedBy, &author.DisplayName, &author.AvatarURL, &author.Bio}
+}
+
+// setPostAuthor attaches the scanned byline when the post has an author
+func setPostAuthor(post *models.Post, author models.Author) {
+	if post.AuthorID == nil {
+		return
+	}
+	author.ID = *post.AuthorID
+	post.Author = &author
+}
+
+// GetAuthor returns the public profile of an author with a page of their published posts.
+// It takes the same query parameters as GET /api/posts. Accounts that are deactivated or
+// have no public post are not authors as far as visitors are concerned and get 404.
+func GetAuthor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+		return
+	}
+
+	var response models.AuthorResponse
+	err = database.DB.QueryRow(`SELECT a.id, COALESCE(a.display_name, ''), COALESCE(a.avatar_url, ''), COALESCE(a.bio, '')
+		FROM admin a
+		WHERE a.id = $1 AND COALESCE(a.is_active, TRUE) AND EXISTS (
+			SELECT 1 FROM posts p JOIN categories c ON p.category_id = c.id
+			WHERE p.author_id = a.id AND `+strings.Join(publicPostConditions, " AND ")+`)`, id).Scan(
+		&response.Author.ID, &response.Author.DisplayName, &response.Author.AvatarURL, &response.Author.Bio)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch author"})
+		return
+	}
+
+	conditions := append([]string{"p.author_id = $1"}, publicPostConditions...)
+	page, ok := queryPosts(c, conditions, []interface{}{id})
+	if !ok {
+		return
+	}
+	response.PostListResponse = *page
+
+	c.JSON(http.StatusOK, response)
+}
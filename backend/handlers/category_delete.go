@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"house-design-backend/database"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCategoryDeletionPreview reports the subcategories and posts that deleting a category
+// would affect, so that a strategy can be chosen before calling DeleteCategory
+func GetCategoryDeletionPreview(c *gin.Context) {
+	preview, err := categoryDeletionPreview(database.DB, c.Param("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview category deletion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// DeleteCategory moves a category to the trash. What happens to its content depends on the
+// strategy query parameter:
+//   - block (default) refuses with 409 and the deletion preview unless the category is empty
+//   - reassign moves its posts and direct subcategories to target_id first
+//   - cascade trashes its subcategories and the posts inside them along with it
+//
+// RestoreTrashedCategory brings back everything trashed with the category.
+func DeleteCategory(c *gin.Context) {
+	id := c.Param("id")
+	strategy := c.DefaultQuery("strategy", "block")
+	if strategy != "block" && strategy != "reassign" && strategy != "cascade" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid strategy, use block, reassign or cascade"})
+		return
+	}
+	var targetID uint64
+	if strategy == "reassign" {
+		var err error
+		if targetID, err = strconv.ParseUint(c.Query("target_id"), 10, 32); err != nil || targetID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_id is required to reassign the category's content"})
+			return
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	// The lock keeps the category's content from being reassigned or trashed concurrently
+	var locked uint
+	err = tx.QueryRow("SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&locked)
+	if err == nil {
+		var preview models.CategoryDeletionPreview
+		if preview, err = categoryDeletionPreview(tx, id); err == nil && strategy == "block" && !preview.Empty {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "The category still contains posts or subcategories, choose the reassign or cascade strategy",
+				"preview": preview,
+			})
+			return
+		}
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		return
+	}
+
+	response := gin.H{"message": "Category moved to trash", "strategy": strategy}
+	if strategy == "reassign" {
+		var targetExists, targetInside bool
+		err := tx.QueryRow(`WITH RECURSIVE tree AS (
+				SELECT id FROM categories WHERE id = $1
+				UNION ALL
+				SELECT child.id FROM categories child JOIN tree ON child.parent_id = tree.id
+			)
+			SELECT EXISTS(SELECT 1 FROM categories WHERE id = $2 AND deleted_at IS NULL),
+				EXISTS(SELECT 1 FROM tree WHERE id = $2)`, id, targetID).Scan(&targetExists, &targetInside)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check target category"})
+			return
+		}
+		if !targetExists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Target category not found"})
+			return
+		}
+		if targetInside {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Target category must not be the category or one of its subcategories"})
+			return
+		}
+
+		categories, posts, err := reassignCategoryContent(tx, id, targetID, c.GetUint("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reassign category content"})
+			return
+		}
+		response["target_id"] = targetID
+		response["reassigned_categories"] = categories
+		response["reassigned_posts"] = posts
+	}
+
+	categories, posts, err := trashCategory(tx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	response["trashed_categories"] = categories
+	response["trashed_posts"] = posts
+	c.JSON(http.StatusOK, response)
+}
+
+// categoryDeletionPreview counts the live subcategories and posts below a live category.
+// It returns sql.ErrNoRows when there is no such category.
+func categoryDeletionPreview(q database.Queryer, categoryID interface{}) (models.CategoryDeletionPreview, error) {
+	preview := models.CategoryDeletionPreview{Children: []models.CategoryDeletionChild{}}
+	err := q.QueryRow(`SELECT c.id, c.name, c.slug,
+			(SELECT COUNT(*) FROM posts p WHERE p.category_id = c.id AND p.deleted_at IS NULL)
+		FROM categories c WHERE c.id = $1 AND c.deleted_at IS NULL`, categoryID).Scan(
+		&preview.ID, &preview.Name, &preview.Slug, &preview.PostCount)
+	if err != nil {
+		return preview, err
+	}
+
+	// tree maps every live category below each direct child to that child
+	rows, err := q.Query(`WITH RECURSIVE tree AS (
+			SELECT id, id AS child_id FROM categories WHERE parent_id = $1 AND deleted_at IS NULL
+			UNION ALL
+			SELECT sub.id, tree.child_id FROM categories sub JOIN tree ON sub.parent_id = tree.id
+			WHERE sub.deleted_at IS NULL
+		)
+		SELECT c.id, c.name, c.slug,
+			(SELECT COUNT(*) FROM tree WHERE tree.child_id = c.id) - 1,
+			(SELECT COUNT(*) FROM posts p JOIN tree ON tree.id = p.category_id
+				WHERE tree.child_id = c.id AND p.deleted_at IS NULL)
+		FROM categories c
+		WHERE c.parent_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.display_order ASC, c.order_index ASC, c.id ASC`, categoryID)
+	if err != nil {
+		return preview, err
+	}
+	defer rows.Close()
+
+	preview.TotalPostCount = preview.PostCount
+	for rows.Next() {
+		var child models.CategoryDeletionChild
+		if err := rows.Scan(&child.ID, &child.Name, &child.Slug, &child.SubcategoryCount, &child.PostCount); err != nil {
+			return preview, err
+		}
+		preview.SubcategoryCount += child.SubcategoryCount + 1
+		preview.TotalPostCount += child.PostCount
+		preview.Children = append(preview.Children, child)
+	}
+	preview.Empty = preview.SubcategoryCount == 0 && preview.PostCount == 0
+	return preview, rows.Err()
+}
+
+// reassignCategoryContent moves the live posts and direct subcategories of a category to
+// another category and returns how many of each it moved. The levels of the moved
+// subcategories and everything below them follow their new parent.
+func reassignCategoryContent(tx *sql.Tx, categoryID interface{}, targetID uint64, userID uint) (int64, int64, error) {
+	result, err := tx.Exec(`UPDATE categories SET parent_id = $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE parent_id = $1 AND deleted_at IS NULL`, categoryID, targetID)
+	if err != nil {
+		return 0, 0, err
+	}
+	categories, _ := result.RowsAffected()
+
+	if categories > 0 {
+		_, err = tx.Exec(`WITH RECURSIVE tree AS (
+				SELECT id, level FROM categories WHERE id = $1
+				UNION ALL
+				SELECT child.id, tree.level + 1 FROM categories child JOIN tree ON child.parent_id = tree.id
+			)
+			UPDATE categories c SET level = tree.level
+			FROM tree WHERE c.id = tree.id AND c.level <> tree.level`, targetID)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	result, err = tx.Exec(`UPDATE posts SET category_id = $2, last_edited_by = NULLIF($3, 0), version = version + 1,
+		updated_at = CURRENT_TIMESTAMP
+		WHERE category_id = $1 AND deleted_at IS NULL`, categoryID, targetID, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	posts, _ := result.RowsAffected()
+	return categories, posts, nil
+}
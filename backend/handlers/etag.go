@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Posts and categories carry a version that every edit increments. Their entity tag is the
+// version in quotes, and updates must send it back in If-Match so that an edit based on a
+// stale copy is refused instead of silently overwriting someone else's changes.
+
+// versionETag is the entity tag of a resource at a version
+func versionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// setVersionETag sets the ETag header of a response carrying a resource at a version
+func setVersionETag(c *gin.Context, version int) {
+	c.Header("ETag", versionETag(version))
+}
+
+// requireIfMatch returns the request's If-Match header, responding 428 when it is missing
+func requireIfMatch(c *gin.Context) (string, bool) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the current ETag is required"})
+		return "", false
+	}
+	return ifMatch, true
+}
+
+// ifMatchVersion reports whether an If-Match header matches a version. "*" matches any
+// version; bare version numbers and weak tags are accepted for clients that send the
+// version field rather than the ETag.
+func ifMatchVersion(ifMatch string, version int) bool {
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.Trim(strings.TrimPrefix(strings.TrimSpace(tag), "W/"), `"`)
+		if tag == "*" || tag == strconv.Itoa(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// respondVersionConflict answers 412 with the server's current copy of the resource
+func respondVersionConflict(c *gin.Context, version int, current interface{}) {
+	setVersionETag(c, version)
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   "The resource was changed by someone else, review the current copy and retry",
+		"current": current,
+	})
+}
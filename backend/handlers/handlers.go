@@ -15,9 +15,12 @@ import (
 	"house-design-backend/database"
 	"house-design-backend/middleware"
 	"house-design-backend/models"
+	"house-design-backend/sanitize"
+	"house-design-backend/slug"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -50,12 +53,22 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// Refuse to check the password while the username or IP is locked out
+	if !checkLoginLockout(c, loginReq.Username) {
+		return
+	}
+
 	var admin models.Admin
-	err := database.DB.QueryRow("SELECT id, username, password FROM admin WHERE username = $1",
-		loginReq.Username).Scan(&admin.ID, &admin.Username, &admin.Password)
+	err := database.DB.QueryRow(`SELECT id, username, password, COALESCE(email, ''), COALESCE(display_name, ''), role,
+		COALESCE(is_active, TRUE), COALESCE(totp_enabled, FALSE), COALESCE(must_change_password, FALSE)
+		FROM admin WHERE username = $1`,
+		loginReq.Username).Scan(&admin.ID, &admin.Username, &admin.Password, &admin.Email, &admin.DisplayName, &admin.Role,
+		&admin.IsActive, &admin.TwoFactorEnabled, &admin.MustChangePassword)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
+			registerLoginFailure(c, loginReq.Username)
+			recordLoginAttempt(c, loginReq.Username, false, "unknown_user")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 			return
 		}
@@ -66,33 +79,127 @@ func Login(c *gin.Context) {
 	// Check password
 	err = bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(loginReq.Password))
 	if err != nil {
+		registerLoginFailure(c, loginReq.Username)
+		recordLoginAttempt(c, loginReq.Username, false, "invalid_password")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	// Generate JWT token
-	token, err := middleware.GenerateToken(admin.ID, admin.Username)
+	if !admin.IsActive {
+		recordLoginAttempt(c, loginReq.Username, false, "disabled")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
+
+	// Accounts with 2FA get a challenge instead of tokens; failure counters stay until the code is verified
+	if admin.TwoFactorEnabled {
+		challenge, err := middleware.GenerateTwoFactorChallenge(admin.ID, admin.Username)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		c.JSON(http.StatusOK, models.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+			ExpiresIn:         int(middleware.TwoFactorChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	clearLoginFailures(loginReq.Username)
+	recordLoginAttempt(c, loginReq.Username, true, "")
+
+	// Open a session and generate the token pair
+	response, err := issueTokens(c, models.Admin{ID: admin.ID, Username: admin.Username, Email: admin.Email, DisplayName: admin.DisplayName, Role: admin.Role, IsActive: admin.IsActive,
+		MustChangePassword: admin.MustChangePassword})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.LoginResponse{
-		Token: token,
-		Admin: models.Admin{ID: admin.ID, Username: admin.Username},
-	})
-}
-
-func Logout(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+	c.JSON(http.StatusOK, response)
 }
 
 // Categories handlers
+
+// GetCategories returns the active categories shown on the public site. A child of an
+// inactive parent is hidden as well.
 func GetCategories(c *gin.Context) {
-	rows, err := database.DB.Query(`SELECT id, name, slug, description, COALESCE(thumbnail_url, '') as thumbnail_url, COALESCE(category_type, 'parent') as category_type, parent_id, level, order_index, display_order, is_active,
-		COALESCE(meta_title, '') as meta_title, COALESCE(meta_description, '') as meta_description, COALESCE(meta_keywords, '') as meta_keywords, COALESCE(og_image_url, '') as og_image_url,
-		created_at, updated_at
-		FROM categories ORDER BY category_type ASC, level ASC, display_order ASC, order_index ASC, created_at ASC`)
+	listCategories(c, []string{
+		liveCategoryCondition,
+		"c.is_active = TRUE",
+		"(c.parent_id IS NULL OR EXISTS (SELECT 1 FROM categories p WHERE p.id = c.parent_id AND p.is_active = TRUE))",
+	}, nil)
+}
+
+// GetAdminCategories returns every category including inactive ones. Supports ?is_active=true|false.
+func GetAdminCategories(c *gin.Context) {
+	conditions := []string{liveCategoryCondition}
+	var args []interface{}
+	if isActive := c.Query("is_active"); isActive != "" {
+		active, err := strconv.ParseBool(isActive)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid is_active filter"})
+			return
+		}
+		args = append(args, active)
+		conditions = append(conditions, fmt.Sprintf("c.is_active = $%d", len(args)))
+	}
+	listCategories(c, conditions, args)
+}
+
+// liveCategoryCondition hides categories in the trash
+const liveCategoryCondition = "c.deleted_at IS NULL"
+
+const categoryColumns = `c.id, c.name, c.slug, c.description, COALESCE(c.thumbnail_url, '') as thumbnail_url, COALESCE(c.category_type, 'parent') as category_type, c.parent_id, c.level, c.order_index, c.display_order, c.is_active,
+		COALESCE(c.meta_title, '') as meta_title, COALESCE(c.meta_description, '') as meta_description, COALESCE(c.meta_keywords, '') as meta_keywords, COALESCE(c.og_image_url, '') as og_image_url,
+		c.version, c.created_at, c.updated_at`
+
+func scanCategory(row interface{ Scan(...interface{}) error }, category *models.Category) error {
+	var parentID sql.NullInt64
+	err := row.Scan(&category.ID, &category.Name, &category.Slug, &category.Description, &category.ThumbnailURL,
+		&category.CategoryType, &parentID, &category.Level, &category.OrderIndex, &category.DisplayOrder, &category.IsActive,
+		&category.MetaTitle, &category.MetaDescription, &category.MetaKeywords, &category.OGImageURL,
+		&category.Version, &category.CreatedAt, &category.UpdatedAt)
+	if parentID.Valid {
+		parentIDUint := uint(parentID.Int64)
+		category.ParentID = &parentIDUint
+	}
+	return err
+}
+
+// loadCategory reads one category, unless it is in the trash, without its children
+func loadCategory(id interface{}) (models.Category, error) {
+	var category models.Category
+	err := scanCategory(database.DB.QueryRow(`SELECT `+categoryColumns+` FROM categories c WHERE c.id = $1 AND `+liveCategoryCondition, id), &category)
+	return category, err
+}
+
+// GetAdminCategory returns one category, active or not, with its ETag for If-Match
+func GetAdminCategory(c *gin.Context) {
+	category, err := loadCategory(c.Param("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category"})
+		return
+	}
+
+	setVersionETag(c, category.Version)
+	c.JSON(http.StatusOK, category)
+}
+
+func listCategories(c *gin.Context, conditions []string, args []interface{}) {
+	query := `SELECT ` + categoryColumns + `
+		FROM categories c`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY c.category_type ASC, c.level ASC, c.display_order ASC, c.order_index ASC, c.created_at ASC"
+
+	rows, err := database.DB.Query(query, args...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
 		return
@@ -104,21 +211,11 @@ func GetCategories(c *gin.Context) {
 
 	for rows.Next() {
 		var category models.Category
-		var parentID sql.NullInt64
-		err := rows.Scan(&category.ID, &category.Name, &category.Slug, &category.Description, &category.ThumbnailURL,
-			&category.CategoryType, &parentID, &category.Level, &category.OrderIndex, &category.DisplayOrder, &category.IsActive,
-			&category.MetaTitle, &category.MetaDescription, &category.MetaKeywords, &category.OGImageURL,
-			&category.CreatedAt, &category.UpdatedAt)
-		if err != nil {
+		if err := scanCategory(rows, &category); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan category"})
 			return
 		}
 
-		if parentID.Valid {
-			parentIDUint := uint(parentID.Int64)
-			category.ParentID = &parentIDUint
-		}
-
 		// Initialize Children slice
 		category.Children = []models.Category{}
 
@@ -161,8 +258,12 @@ func CreateCategory(c *gin.Context) {
 	fmt.Printf("Creating category - Name: %s, Slug: %s, ParentID: %v\n", category.Name, category.Slug, category.ParentID)
 
 	// Generate slug if not provided
+	category.Slug = slug.Make(category.Slug)
+	if category.Slug == "" {
+		category.Slug = slug.Make(category.Name)
+	}
 	if category.Slug == "" {
-		category.Slug = generateSlug(category.Name)
+		category.Slug = "category"
 	}
 
 	// Calculate level based on parent
@@ -170,7 +271,7 @@ func CreateCategory(c *gin.Context) {
 		// Get parent level and slug for subcategory slug generation
 		var parentLevel int
 		var parentSlug string
-		err := database.DB.QueryRow("SELECT level, slug FROM categories WHERE id = $1", *category.ParentID).Scan(&parentLevel, &parentSlug)
+		err := database.DB.QueryRow("SELECT level, slug FROM categories WHERE id = $1 AND deleted_at IS NULL", *category.ParentID).Scan(&parentLevel, &parentSlug)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent category"})
 			return
@@ -179,7 +280,7 @@ func CreateCategory(c *gin.Context) {
 
 		// Make slug unique for subcategories by prefixing with parent slug (only if not already prefixed)
 		if !strings.HasPrefix(category.Slug, parentSlug+"-") {
-			category.Slug = parentSlug + "-" + category.Slug
+			category.Slug = slug.Make(parentSlug + "-" + category.Slug)
 		}
 	} else {
 		category.Level = 0
@@ -199,7 +300,7 @@ func CreateCategory(c *gin.Context) {
 		originalSlug := category.Slug
 		counter := 1
 		for {
-			category.Slug = fmt.Sprintf("%s-%d", originalSlug, counter)
+			category.Slug = slug.WithSuffix(originalSlug, counter)
 			err := database.DB.QueryRow("SELECT COUNT(*) FROM categories WHERE slug = $1", category.Slug).Scan(&existingCount)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slug uniqueness"})
@@ -243,6 +344,10 @@ func CreateCategory(c *gin.Context) {
 
 func UpdateCategory(c *gin.Context) {
 	id := c.Param("id")
+	ifMatch, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
 	var category models.Category
 	if err := c.ShouldBindJSON(&category); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -259,24 +364,28 @@ func UpdateCategory(c *gin.Context) {
 	err := database.DB.QueryRow(`SELECT name, slug, description, COALESCE(thumbnail_url, '') as thumbnail_url,
 		COALESCE(category_type, 'parent') as category_type, parent_id, level, order_index, is_active,
 		COALESCE(meta_title, '') as meta_title, COALESCE(meta_description, '') as meta_description,
-		COALESCE(meta_keywords, '') as meta_keywords, COALESCE(og_image_url, '') as og_image_url
-		FROM categories WHERE id = $1`, id).Scan(
+		COALESCE(meta_keywords, '') as meta_keywords, COALESCE(og_image_url, '') as og_image_url, version
+		FROM categories WHERE id = $1 AND deleted_at IS NULL`, id).Scan(
 		&existingCategory.Name, &existingCategory.Slug, &existingCategory.Description,
 		&existingCategory.ThumbnailURL, &existingCategory.CategoryType, &existingCategory.ParentID,
 		&existingCategory.Level, &existingCategory.OrderIndex, &existingCategory.IsActive,
 		&existingCategory.MetaTitle, &existingCategory.MetaDescription,
-		&existingCategory.MetaKeywords, &existingCategory.OGImageURL)
+		&existingCategory.MetaKeywords, &existingCategory.OGImageURL, &existingCategory.Version)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
 	}
+	if !ifMatchVersion(ifMatch, existingCategory.Version) {
+		respondCategoryConflict(c, id)
+		return
+	}
 
 	// Update fields - always update these core fields from request
 	if category.Name != "" {
 		existingCategory.Name = category.Name
 	}
-	if category.Slug != "" {
-		existingCategory.Slug = category.Slug
+	if newSlug := slug.Make(category.Slug); newSlug != "" {
+		existingCategory.Slug = newSlug
 	}
 	// Always update these fields even if empty (user might want to clear them)
 	existingCategory.Description = category.Description
@@ -302,7 +411,7 @@ func UpdateCategory(c *gin.Context) {
 	if category.ParentID != nil {
 		// Setting a parent
 		var parentLevel int
-		err := database.DB.QueryRow("SELECT level FROM categories WHERE id = $1", *category.ParentID).Scan(&parentLevel)
+		err := database.DB.QueryRow("SELECT level FROM categories WHERE id = $1 AND deleted_at IS NULL", *category.ParentID).Scan(&parentLevel)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent category"})
 			return
@@ -329,11 +438,13 @@ func UpdateCategory(c *gin.Context) {
 	fmt.Printf("SEO fields to update: meta_title='%s', meta_description='%s', meta_keywords='%s', og_image_url='%s'\n",
 		existingCategory.MetaTitle, existingCategory.MetaDescription, existingCategory.MetaKeywords, existingCategory.OGImageURL)
 
+	// The version condition refuses the write when someone saved the category after it was read
 	result, err := database.DB.Exec(`UPDATE categories SET name = $2, slug = $3, description = $4, thumbnail_url = $5, category_type = $6, parent_id = $7,
-		level = $8, order_index = $9, is_active = $10, meta_title = $11, meta_description = $12, meta_keywords = $13, og_image_url = $14, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		level = $8, order_index = $9, is_active = $10, meta_title = $11, meta_description = $12, meta_keywords = $13, og_image_url = $14,
+		version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND version = $15`,
 		id, existingCategory.Name, existingCategory.Slug, existingCategory.Description, existingCategory.ThumbnailURL,
 		existingCategory.CategoryType, existingCategory.ParentID, existingCategory.Level, existingCategory.OrderIndex, existingCategory.IsActive,
-		existingCategory.MetaTitle, existingCategory.MetaDescription, existingCategory.MetaKeywords, existingCategory.OGImageURL)
+		existingCategory.MetaTitle, existingCategory.MetaDescription, existingCategory.MetaKeywords, existingCategory.OGImageURL, existingCategory.Version)
 	if err != nil {
 		fmt.Printf("SQL UPDATE ERROR: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
@@ -342,24 +453,33 @@ func UpdateCategory(c *gin.Context) {
 
 	rowsAffected, _ := result.RowsAffected()
 	fmt.Printf("UPDATE completed, rows affected: %d\n", rowsAffected)
+	if rowsAffected == 0 {
+		respondCategoryConflict(c, id)
+		return
+	}
+	existingCategory.Version++
 
 	categoryID, _ := strconv.ParseUint(id, 10, 32)
 	existingCategory.ID = uint(categoryID)
 
 	fmt.Printf("Returning updated category: %+v\n", existingCategory)
+	setVersionETag(c, existingCategory.Version)
 	c.JSON(http.StatusOK, existingCategory)
 }
 
-func DeleteCategory(c *gin.Context) {
-	id := c.Param("id")
-
-	_, err := database.DB.Exec("DELETE FROM categories WHERE id = $1", id)
+// respondCategoryConflict answers 412 with the current copy of a category, or 404 when it
+// was deleted in the meantime
+func respondCategoryConflict(c *gin.Context, id string) {
+	current, err := loadCategory(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category"})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+	respondVersionConflict(c, current.Version, current)
 }
 
 func UpdateCategoryOrder(c *gin.Context) {
@@ -398,73 +518,84 @@ func UpdateCategoryOrder(c *gin.Context) {
 }
 
 // Posts handlers
-func GetPosts(c *gin.Context) {
-	categoryID := c.Query("category")
-
-	query := `SELECT p.id, p.title, p.content, p.summary, p.image_url, p.category_id, 
-			  p.published, p.created_at, p.updated_at, 
-			  COALESCE(p.meta_title, '') as meta_title, COALESCE(p.meta_description, '') as meta_description,
-			  COALESCE(p.focus_keywords, '') as focus_keywords, COALESCE(p.og_image_url, '') as og_image_url, COALESCE(p.slug, '') as slug,
-			  c.name, c.slug, c.description
-			  FROM posts p 
-			  JOIN categories c ON p.category_id = c.id`
-
-	var rows *sql.Rows
-	var err error
-
-	if categoryID != "" {
-		query += " WHERE p.category_id = $1 ORDER BY p.created_at DESC"
-		rows, err = database.DB.Query(query, categoryID)
-	} else {
-		query += " ORDER BY p.created_at DESC"
-		rows, err = database.DB.Query(query)
-	}
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
-		return
-	}
-	defer rows.Close()
+// livePostCondition hides posts in the trash. Every post query but the trash's own uses it.
+const livePostCondition = "p.deleted_at IS NULL"
+
+// publicPostConditions restrict post queries to what anonymous visitors may see:
+// published posts inside their publish_at/unpublish_at window, in an active category whose
+// parent, if any, is active too. A passed publish_at counts as published even before the
+// scheduler has flipped the flag, and a future one hides a post already marked published.
+var publicPostConditions = []string{
+	livePostCondition,
+	"(p.publish_at <= NOW() OR (p.publish_at IS NULL AND p.published = TRUE))",
+	"(p.unpublish_at IS NULL OR p.unpublish_at > NOW())",
+	"c.is_active = TRUE",
+	"(c.parent_id IS NULL OR EXISTS (SELECT 1 FROM categories pc WHERE pc.id = c.parent_id AND pc.is_active = TRUE))",
+}
 
-	var posts []models.Post
-	for rows.Next() {
-		var post models.Post
-		var category models.Category
+// GetPosts returns a page of published posts. See listPosts for the supported query parameters.
+func GetPosts(c *gin.Context) {
+	listPosts(c, append([]string{}, publicPostConditions...), nil)
+}
 
-		err := rows.Scan(&post.ID, &post.Title, &post.Content, &post.Summary, &post.ImageURL,
-			&post.CategoryID, &post.Published, &post.CreatedAt, &post.UpdatedAt,
-			&post.MetaTitle, &post.MetaDescription, &post.FocusKeywords, &post.OGImageURL, &post.Slug,
-			&category.Name, &category.Slug, &category.Description)
+// GetAdminPosts returns a page of posts including drafts and posts in inactive categories.
+// On top of the listPosts parameters it supports ?published=true|false and ?category_active=true|false.
+func GetAdminPosts(c *gin.Context) {
+	conditions := []string{livePostCondition}
+	var args []interface{}
+	for _, filter := range []struct{ param, column string }{
+		{"published", "p.published"},
+		{"category_active", "c.is_active"},
+	} {
+		value := c.Query(filter.param)
+		if value == "" {
+			continue
+		}
+		flag, err := strconv.ParseBool(value)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan post"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + filter.param + " filter"})
 			return
 		}
-
-		category.ID = post.CategoryID
-		post.Category = category
-		posts = append(posts, post)
+		args = append(args, flag)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", filter.column, len(args)))
 	}
-
-	c.JSON(http.StatusOK, posts)
+	listPosts(c, conditions, args)
 }
 
+// GetPost returns a published post; drafts and posts in inactive categories are reported as not found
 func GetPost(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
 		return
 	}
+	getPost(c, append([]string{"p.id = $1"}, publicPostConditions...), id)
+}
 
-	var post models.Post
-	err = database.DB.QueryRow(`SELECT id, title, content, summary, image_url, category_id, published, 
-		COALESCE(meta_title, '') as meta_title, COALESCE(meta_description, '') as meta_description,
-		COALESCE(focus_keywords, '') as focus_keywords, COALESCE(og_image_url, '') as og_image_url, COALESCE(slug, '') as slug,
-		created_at, updated_at
-		FROM posts WHERE id = $1`, id).Scan(
-		&post.ID, &post.Title, &post.Content, &post.Summary, &post.ImageURL, &post.CategoryID,
-		&post.Published, &post.MetaTitle, &post.MetaDescription, &post.FocusKeywords, &post.OGImageURL, &post.Slug,
-		&post.CreatedAt, &post.UpdatedAt)
+// GetPostBySlug returns a published post by its slug
+func GetPostBySlug(c *gin.Context) {
+	getPost(c, append([]string{"p.slug = $1"}, publicPostConditions...), c.Param("slug"))
+}
+
+// GetCategoryPostBySlug returns a published post by category slug and post slug,
+// for URLs like /category/:slug/:post_slug
+func GetCategoryPostBySlug(c *gin.Context) {
+	getPost(c, append([]string{"c.slug = $1", "p.slug = $2"}, publicPostConditions...), c.Param("slug"), c.Param("post_slug"))
+}
 
+// GetAdminPost returns any post, including drafts
+func GetAdminPost(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+	getPost(c, []string{"p.id = $1", livePostCondition}, id)
+}
+
+func getPost(c *gin.Context, conditions []string, args ...interface{}) {
+	post, err := loadPost(conditions, args...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
@@ -474,19 +605,41 @@ func GetPost(c *gin.Context) {
 		return
 	}
 
-	// Get category information
-	var category models.Category
-	err = database.DB.QueryRow(`SELECT id, name, slug, description, created_at, updated_at
-		FROM categories WHERE id = $1`, post.CategoryID).Scan(
-		&category.ID, &category.Name, &category.Slug, &category.Description,
-		&category.CreatedAt, &category.UpdatedAt)
+	setVersionETag(c, post.Version)
+	c.JSON(http.StatusOK, post)
+}
+
+// loadPost reads the first post matching conditions with its category, author and tags
+func loadPost(conditions []string, args ...interface{}) (models.Post, error) {
+	query := `SELECT p.id, p.title, p.content, p.summary, p.image_url, p.category_id, p.published, COALESCE(p.views, 0),
+		COALESCE(p.meta_title, '') as meta_title, COALESCE(p.meta_description, '') as meta_description,
+		COALESCE(p.focus_keywords, '') as focus_keywords, COALESCE(p.og_image_url, '') as og_image_url,
+		COALESCE(p.canonical_url, '') as canonical_url, p.slug, p.publish_at, p.unpublish_at, p.version, p.created_at, p.updated_at,
+		` + postAuthorColumns + `,
+		c.name, c.slug, c.description, c.created_at, c.updated_at
+		FROM posts p JOIN categories c ON p.category_id = c.id` + postAuthorJoin + `
+		WHERE ` + strings.Join(conditions, " AND ")
 
-	if err == nil {
-		category.ID = post.CategoryID
-		post.Category = category
+	var post models.Post
+	var category models.Category
+	var author models.Author
+	dest := []interface{}{&post.ID, &post.Title, &post.Content, &post.Summary, &post.ImageURL, &post.CategoryID,
+		&post.Published, &post.Views, &post.MetaTitle, &post.MetaDescription, &post.FocusKeywords, &post.OGImageURL, &post.CanonicalURL, &post.Slug,
+		&post.PublishAt, &post.UnpublishAt, &post.Version, &post.CreatedAt, &post.UpdatedAt}
+	dest = append(dest, postAuthorDest(&post, &author)...)
+	err := database.DB.QueryRow(query, args...).Scan(append(dest,
+		&category.Name, &category.Slug, &category.Description, &category.CreatedAt, &category.UpdatedAt)...)
+	if err != nil {
+		return post, err
 	}
 
-	c.JSON(http.StatusOK, post)
+	category.ID = post.CategoryID
+	post.Category = category
+	setPostAuthor(&post, author)
+
+	posts := []models.Post{post}
+	err = loadPostTags(posts)
+	return posts[0], err
 }
 
 func CreatePost(c *gin.Context) {
@@ -496,54 +649,249 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
+	if err := validatePostSchedule(&post); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkPostCategory(c, post.CategoryID) {
+		return
+	}
+	sanitizePostContent(&post)
+
+	uniqueSlug, err := uniquePostSlug(post.Slug, post.Title, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slug uniqueness"})
+		return
+	}
+	post.Slug = uniqueSlug
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	// New posts start unviewed whatever the client sent, and belong to whoever creates them
+	post.Views = 0
+	userID := c.GetUint("user_id")
+	post.AuthorID = &userID
+	post.LastEditedBy = &userID
+
 	var newID uint
-	err := database.DB.QueryRow(`INSERT INTO posts (title, content, summary, image_url, category_id, published, views, meta_title, meta_description, focus_keywords, og_image_url, slug)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`,
-		post.Title, post.Content, post.Summary, post.ImageURL, post.CategoryID, post.Published, post.Views, post.MetaTitle, post.MetaDescription, post.FocusKeywords, post.OGImageURL, post.Slug).Scan(&newID)
+	err = tx.QueryRow(`INSERT INTO posts (title, content, summary, image_url, category_id, published, views, meta_title, meta_description, focus_keywords, og_image_url, slug, publish_at, unpublish_at, canonical_url, author_id, last_edited_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $16) RETURNING id`,
+		post.Title, post.Content, post.Summary, post.ImageURL, post.CategoryID, post.Published, post.Views, post.MetaTitle, post.MetaDescription, post.FocusKeywords, post.OGImageURL, post.Slug,
+		post.PublishAt, post.UnpublishAt, post.CanonicalURL, userID).Scan(&newID)
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Slug already exists"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
 	}
 
+	if post.TagIDs != nil {
+		if err := database.SetPostTags(tx, newID, *post.TagIDs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save tags"})
+			return
+		}
+	}
+
+	if err := savePostRevision(tx, newID, c.GetUint("user_id"), 0); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save revision"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	post.ID = newID
+	post.TagIDs = nil
+	posts := []models.Post{post}
+	if err := loadPostTags(posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+	post = posts[0]
 
 	c.JSON(http.StatusCreated, post)
 }
 
 func UpdatePost(c *gin.Context) {
-	id := c.Param("id")
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+	ifMatch, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
 	var post models.Post
 	if err := c.ShouldBindJSON(&post); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	_, err := database.DB.Exec(`UPDATE posts SET title = $2, content = $3, summary = $4, image_url = $5,
-		category_id = $6, published = $7, views = $8, meta_title = $9, meta_description = $10, focus_keywords = $11,
-		og_image_url = $12, slug = $13, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
-		id, post.Title, post.Content, post.Summary, post.ImageURL, post.CategoryID, post.Published,
-		post.Views, post.MetaTitle, post.MetaDescription, post.FocusKeywords, post.OGImageURL, post.Slug)
+	if err := validatePostSchedule(&post); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatePost(c, uint(postID), post, ifMatch)
+}
+
+// updatePost writes every editable field of post to the post with the given ID and
+// responds with the result, unless the post's version no longer matches ifMatch.
+// Shared by the PUT and PATCH handlers.
+func updatePost(c *gin.Context, postID uint, post models.Post, ifMatch string) {
+	if !checkPostCategory(c, post.CategoryID) {
+		return
+	}
+	sanitizePostContent(&post)
+
+	// An empty slug keeps the current one so public URLs do not change when the title does
+	if post.Slug != "" {
+		uniqueSlug, err := uniquePostSlug(post.Slug, post.Title, postID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slug uniqueness"})
+			return
+		}
+		post.Slug = uniqueSlug
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	version, err := lockPostForUpdate(tx, postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock post"})
+		return
+	}
+	if !ifMatchVersion(ifMatch, version) {
+		tx.Rollback()
+		respondPostConflict(c, postID)
+		return
+	}
+
+	// views is left alone, only tracked views move the counter
+	err = tx.QueryRow(`UPDATE posts SET title = $2, content = $3, summary = $4, image_url = $5,
+		category_id = $6, published = $7, meta_title = $8, meta_description = $9, focus_keywords = $10,
+		og_image_url = $11, slug = COALESCE(NULLIF($12, ''), slug), publish_at = $13, unpublish_at = $14,
+		canonical_url = $15, last_edited_by = $16, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 RETURNING slug, COALESCE(views, 0), author_id, last_edited_by, version`,
+		postID, post.Title, post.Content, post.Summary, post.ImageURL, post.CategoryID, post.Published,
+		post.MetaTitle, post.MetaDescription, post.FocusKeywords, post.OGImageURL, post.Slug,
+		post.PublishAt, post.UnpublishAt, post.CanonicalURL, c.GetUint("user_id")).Scan(&post.Slug, &post.Views, &post.AuthorID, &post.LastEditedBy, &post.Version)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Slug already exists"})
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			respondFieldErrors(c, fieldErrors{"category_id": "does not exist"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
 		return
 	}
 
-	postID, _ := strconv.ParseUint(id, 10, 32)
-	post.ID = uint(postID)
+	if post.TagIDs != nil {
+		if err := database.SetPostTags(tx, postID, *post.TagIDs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save tags"})
+			return
+		}
+	}
+
+	if err := savePostRevision(tx, postID, c.GetUint("user_id"), 0); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save revision"})
+		return
+	}
 
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	post.ID = postID
+	post.TagIDs = nil
+	posts := []models.Post{post}
+	if err := loadPostTags(posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+	post = posts[0]
+
+	setVersionETag(c, post.Version)
 	c.JSON(http.StatusOK, post)
 }
 
+// checkPostCategory responds 400 and returns false unless categoryID is a category outside
+// the trash, so that posts are never saved into a deleted category
+func checkPostCategory(c *gin.Context, categoryID uint) bool {
+	exists, err := rowExists("SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1 AND deleted_at IS NULL)", categoryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check category"})
+		return false
+	}
+	if !exists {
+		respondFieldErrors(c, fieldErrors{"category_id": "does not exist"})
+		return false
+	}
+	return true
+}
+
+// sanitizePostContent strips what the sanitize policy does not allow from a post's content
+// before it is saved, keeping the report for the response when anything was removed
+func sanitizePostContent(post *models.Post) {
+	content, report := sanitize.HTML(post.Content)
+	post.Content = content
+	if !report.Empty() {
+		post.ContentSanitized = &report
+	}
+}
+
+// respondPostConflict answers 412 with the current copy of a post
+func respondPostConflict(c *gin.Context, postID uint) {
+	current, err := loadPost([]string{"p.id = $1", livePostCondition}, postID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch post"})
+		return
+	}
+	respondVersionConflict(c, current.Version, current)
+}
+
+// DeletePost moves a post to the trash, from where RestoreTrashedPost brings it back
 func DeletePost(c *gin.Context) {
 	id := c.Param("id")
 
-	_, err := database.DB.Exec("DELETE FROM posts WHERE id = $1", id)
+	found, err := trashPost(database.DB, id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
 		return
 	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Post deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Post moved to trash"})
 }
 
 // UploadImage handles image upload for CKEditor
@@ -1059,27 +1407,23 @@ func ReplaceHomepageMedia(c *gin.Context) {
 }
 
 // Helper function to generate slug from title
-func generateSlug(title string) string {
-	slug := strings.ToLower(title)
-	slug = strings.ReplaceAll(slug, " ", "-")
-	slug = strings.ReplaceAll(slug, "'", "")
-	slug = strings.ReplaceAll(slug, "\"", "")
-	slug = strings.ReplaceAll(slug, ".", "")
-	slug = strings.ReplaceAll(slug, ",", "")
-	slug = strings.ReplaceAll(slug, "!", "")
-	slug = strings.ReplaceAll(slug, "?", "")
-	// Remove any non-alphanumeric characters except hyphens
-	var result strings.Builder
-	for _, char := range slug {
-		if (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') || char == '-' {
-			result.WriteRune(char)
+// Home Content handlers
+func GetHomeContent(c *gin.Context) {
+	homeContent, err := loadHomeContent()
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Home content not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch home content"})
 		}
+		return
 	}
-	return result.String()
+
+	c.JSON(http.StatusOK, homeContent)
 }
 
-// Home Content handlers
-func GetHomeContent(c *gin.Context) {
+// loadHomeContent reads the home content record
+func loadHomeContent() (models.HomeContent, error) {
 	var homeContent models.HomeContent
 
 	// Get the first (and should be only) home content record
@@ -1131,16 +1475,7 @@ func GetHomeContent(c *gin.Context) {
 		&homeContent.UpdatedAt,
 	)
 
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Home content not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch home content"})
-		}
-		return
-	}
-
-	c.JSON(http.StatusOK, homeContent)
+	return homeContent, err
 }
 
 func UpdateHomeContent(c *gin.Context) {
@@ -1157,6 +1492,17 @@ func UpdateHomeContent(c *gin.Context) {
 		return
 	}
 
+	if err := saveHomeContent(updateData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update home content"})
+		return
+	}
+
+	// Return the updated content
+	GetHomeContent(c)
+}
+
+// saveHomeContent writes every field of updateData to the home content record
+func saveHomeContent(updateData models.HomeContent) error {
 	// Update the home content (should be only one record)
 	_, err := database.DB.Exec(`
 		UPDATE home_content
@@ -1192,14 +1538,7 @@ func UpdateHomeContent(c *gin.Context) {
 		updateData.Feature4Title,
 		updateData.Feature4Description,
 	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update home content"})
-		return
-	}
-
-	// Return the updated content
-	GetHomeContent(c)
+	return err
 }
 
 // UploadSvgIcon handles SVG icon uploads for the icon selector
@@ -1320,6 +1659,21 @@ type FooterContentResponse struct {
 
 // Footer Content handlers
 func GetFooterContent(c *gin.Context) {
+	response, err := loadFooterContent()
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Footer content not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch footer content"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// loadFooterContent reads the footer content record with its services and social media decoded
+func loadFooterContent() (FooterContentResponse, error) {
 	var footerContent models.FooterContent
 
 	// Get the first (and should be only) footer content record
@@ -1353,12 +1707,7 @@ func GetFooterContent(c *gin.Context) {
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Footer content not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch footer content"})
-		}
-		return
+		return FooterContentResponse{}, err
 	}
 
 	// Parse services JSON string to array
@@ -1410,7 +1759,7 @@ func GetFooterContent(c *gin.Context) {
 		UpdatedAt:     footerContent.UpdatedAt,
 	}
 
-	c.JSON(http.StatusOK, response)
+	return response, nil
 }
 
 func UpdateFooterContent(c *gin.Context) {
@@ -1431,20 +1780,38 @@ func UpdateFooterContent(c *gin.Context) {
 	fmt.Printf("Backend: Services received: %+v\n", updateData.Services)
 	fmt.Printf("Backend: Social media received: %+v\n", updateData.SocialMedia)
 
+	if err := saveFooterContent(updateData); err != nil {
+		fmt.Printf("Backend: Database update error: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update footer content"})
+		return
+	}
+
+	fmt.Printf("Backend: Footer content updated successfully\n")
+
+	// Return the updated content
+	GetFooterContent(c)
+}
+
+// saveFooterContent writes every field of updateData to the footer content record
+func saveFooterContent(updateData FooterContentResponse) error {
+	// Cleared lists are stored as empty arrays rather than null
+	if updateData.Services == nil {
+		updateData.Services = []string{}
+	}
+	if updateData.SocialMedia == nil {
+		updateData.SocialMedia = []SocialMediaItem{}
+	}
+
 	// Convert services array to JSON string
 	servicesJSON, err := json.Marshal(updateData.Services)
 	if err != nil {
-		fmt.Printf("Backend: Error marshaling services: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to process services data"})
-		return
+		return err
 	}
 
 	// Convert social media array to JSON string
 	socialMediaJSON, err := json.Marshal(updateData.SocialMedia)
 	if err != nil {
-		fmt.Printf("Backend: Error marshaling social media: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to process social media data"})
-		return
+		return err
 	}
 
 	fmt.Printf("Backend: Services JSON: %s\n", string(servicesJSON))
@@ -1471,17 +1838,7 @@ func UpdateFooterContent(c *gin.Context) {
 		string(servicesJSON),
 		string(socialMediaJSON),
 	)
-
-	if err != nil {
-		fmt.Printf("Backend: Database update error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update footer content"})
-		return
-	}
-
-	fmt.Printf("Backend: Footer content updated successfully\n")
-
-	// Return the updated content
-	GetFooterContent(c)
+	return err
 }
 
 // GetGlobalSEOSettings retrieves the global SEO settings
@@ -1494,23 +1851,28 @@ func GetGlobalSEOSettings(c *gin.Context) {
 
 	if settings == nil {
 		// No settings found, return empty/default
-		c.JSON(http.StatusOK, models.GlobalSEOSettings{
-			SiteName:               "MMA Architectural Design",
-			DefaultMetaTitle:       "MMA Architectural Design - Thiết Kế & Thi Công Biệt Thự",
-			DefaultMetaDescription: "Chuyên thiết kế và thi công biệt thự, nhà ở hiện đại với phong cách kiến trúc độc đáo. Uy tín tại 37 tỉnh thành, hơn 500 dự án hoàn thành.",
-			CompanyName:            "MMA Architectural Design",
-			CompanyDescription:     "Công ty chuyên thiết kế và thi công biệt thự, nhà ở cao cấp",
-			CompanyAddress:         "123 Đường ABC, Quận XYZ, TP.HCM",
-			CompanyPhone:           "0123 456 789",
-			CompanyEmail:           "contact@mma-design.com",
-			BusinessHours:          "Mo-Fr 08:00-17:00, Sa 08:00-12:00",
-		})
+		c.JSON(http.StatusOK, defaultGlobalSEOSettings())
 		return
 	}
 
 	c.JSON(http.StatusOK, settings)
 }
 
+// defaultGlobalSEOSettings are served until SEO settings are saved for the first time
+func defaultGlobalSEOSettings() models.GlobalSEOSettings {
+	return models.GlobalSEOSettings{
+		SiteName:               "MMA Architectural Design",
+		DefaultMetaTitle:       "MMA Architectural Design - Thiết Kế & Thi Công Biệt Thự",
+		DefaultMetaDescription: "Chuyên thiết kế và thi công biệt thự, nhà ở hiện đại với phong cách kiến trúc độc đáo. Uy tín tại 37 tỉnh thành, hơn 500 dự án hoàn thành.",
+		CompanyName:            "MMA Architectural Design",
+		CompanyDescription:     "Công ty chuyên thiết kế và thi công biệt thự, nhà ở cao cấp",
+		CompanyAddress:         "123 Đường ABC, Quận XYZ, TP.HCM",
+		CompanyPhone:           "0123 456 789",
+		CompanyEmail:           "contact@mma-design.com",
+		BusinessHours:          "Mo-Fr 08:00-17:00, Sa 08:00-12:00",
+	}
+}
+
 // UpdateGlobalSEOSettings updates or creates global SEO settings
 func UpdateGlobalSEOSettings(c *gin.Context) {
 	var updateData models.GlobalSEOSettings
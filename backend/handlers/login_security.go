@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"house-design-backend/config"
+	"house-design-backend/database"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Login throttling policy, configurable through the environment
+func loginMaxFailures() int {
+	return config.GetInt("LOGIN_MAX_FAILURES", 5)
+}
+
+func loginIPMaxFailures() int {
+	return config.GetInt("LOGIN_IP_MAX_FAILURES", 20)
+}
+
+func loginFailureWindow() time.Duration {
+	return config.GetDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute)
+}
+
+func loginLockoutDuration() time.Duration {
+	return config.GetDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+}
+
+func loginBackoffBase() time.Duration {
+	return config.GetDuration("LOGIN_BACKOFF_BASE", time.Second)
+}
+
+func loginUserKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func loginIPKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
+
+// loginBackoff is how long a key must wait after its n-th consecutive failure.
+// The delay doubles with each failure and becomes a full lockout at maxFailures.
+func loginBackoff(failures, maxFailures int) time.Duration {
+	lockout := loginLockoutDuration()
+	if failures >= maxFailures {
+		return lockout
+	}
+	delay := time.Duration(float64(loginBackoffBase()) * math.Pow(2, float64(failures-1)))
+	if delay > lockout {
+		return lockout
+	}
+	return delay
+}
+
+// checkLoginLockout aborts the request with 429 when the username or IP is locked
+func checkLoginLockout(c *gin.Context, username string) bool {
+	lockedUntil, err := database.GetLockedUntil(loginUserKey(username), loginIPKey(c.ClientIP()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+	if lockedUntil.IsZero() {
+		return true
+	}
+
+	recordLoginAttempt(c, username, false, "locked")
+	retryAfter := int(math.Ceil(time.Until(lockedUntil).Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed login attempts, please try again later",
+		"retry_after": retryAfter,
+	})
+	return false
+}
+
+// registerLoginFailure counts a failed password check against both the username and the IP
+func registerLoginFailure(c *gin.Context, username string) {
+	limits := map[string]int{
+		loginUserKey(username):   loginMaxFailures(),
+		loginIPKey(c.ClientIP()): loginIPMaxFailures(),
+	}
+	for key, maxFailures := range limits {
+		failures, err := database.RegisterLoginFailure(key, loginFailureWindow())
+		if err != nil {
+			log.Printf("Failed to register login failure for %s: %v", key, err)
+			continue
+		}
+		if err := database.LockLogin(key, time.Now().Add(loginBackoff(failures, maxFailures))); err != nil {
+			log.Printf("Failed to lock login for %s: %v", key, err)
+		}
+	}
+}
+
+// clearLoginFailures resets the username counter after a successful login. The IP counter is
+// left alone so one valid account cannot be used to keep guessing others from the same address.
+func clearLoginFailures(username string) {
+	if _, err := database.ResetLoginFailures(loginUserKey(username)); err != nil {
+		log.Printf("Failed to reset login failures for %s: %v", username, err)
+	}
+}
+
+func recordLoginAttempt(c *gin.Context, username string, success bool, reason string) {
+	if err := database.RecordLoginAttempt(username, c.ClientIP(), c.Request.UserAgent(), success, reason); err != nil {
+		log.Printf("Failed to record login attempt: %v", err)
+	}
+}
+
+// GetLoginAttempts lists recorded login attempts, newest first.
+// Supports ?username=, ?ip=, ?failed=true and ?limit= (default 100, max 1000).
+func GetLoginAttempts(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	attempts, err := database.ListLoginAttempts(c.Query("username"), c.Query("ip"), c.Query("failed") == "true", limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch login attempts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+// GetLoginLockouts lists the usernames and IP addresses that are currently locked
+func GetLoginLockouts(c *gin.Context) {
+	lockouts, err := database.ListLoginLockouts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lockouts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, lockouts)
+}
+
+// UnlockLogin clears the failure counters of a username and/or IP address
+func UnlockLogin(c *gin.Context) {
+	var req models.UnlockLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var keys []string
+	if strings.TrimSpace(req.Username) != "" {
+		keys = append(keys, loginUserKey(req.Username))
+	}
+	if strings.TrimSpace(req.IPAddress) != "" {
+		keys = append(keys, loginIPKey(strings.TrimSpace(req.IPAddress)))
+	}
+	if len(keys) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username or ip_address is required"})
+		return
+	}
+
+	cleared, err := database.ResetLoginFailures(keys...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Unlocked %s", strings.Join(keys, ", ")),
+		"cleared": cleared,
+	})
+}
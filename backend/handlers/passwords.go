@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"house-design-backend/config"
+	"house-design-backend/database"
+	"house-design-backend/mailer"
+	"house-design-backend/middleware"
+	"house-design-backend/models"
+	"house-design-backend/password"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password reset policy, configurable through the environment
+func passwordResetTTL() time.Duration {
+	return config.GetDuration("PASSWORD_RESET_TTL", time.Hour)
+}
+
+func passwordResetMaxRequests() int {
+	return config.GetInt("PASSWORD_RESET_MAX_REQUESTS", 5)
+}
+
+// passwordResetURL is the frontend page that receives the reset token
+func passwordResetURL(token string) string {
+	baseURL := strings.TrimRight(os.Getenv("APP_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:4200"
+	}
+	return baseURL + "/admin/reset-password?token=" + url.QueryEscape(token)
+}
+
+// ChangePassword replaces the authenticated user's password and logs out their other sessions
+func ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	var username, currentHash string
+	if err := database.DB.QueryRow("SELECT username, password FROM admin WHERE id = $1", userID).Scan(&username, &currentHash); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(currentHash), []byte(req.CurrentPassword)) != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "New password must be different from the current password"})
+		return
+	}
+	if err := password.Validate(req.NewPassword, username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	if err := database.SetAdminPassword(tx, userID, string(hashedPassword)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+	revoked, err := database.RevokeOtherSessions(tx, userID, c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Password changed successfully",
+		"revoked_sessions": revoked,
+	})
+}
+
+// ForgotPassword emails a single-use reset link. The response is the same whether or not the
+// account exists so the endpoint cannot be used to discover usernames or addresses.
+func ForgotPassword(c *gin.Context) {
+	if !mailer.Configured() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Password reset by email is not configured on this server"})
+		return
+	}
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Email == "" && req.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email or username is required"})
+		return
+	}
+
+	// Each request counts against the client IP, reusing the login throttle
+	throttleKey := "reset:" + loginIPKey(c.ClientIP())
+	lockedUntil, err := database.GetLockedUntil(throttleKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !lockedUntil.IsZero() {
+		retryAfter := int(math.Ceil(time.Until(lockedUntil).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many password reset requests, please try again later",
+			"retry_after": retryAfter,
+		})
+		return
+	}
+	if requests, err := database.RegisterLoginFailure(throttleKey, passwordResetTTL()); err != nil {
+		log.Printf("Failed to count password reset request: %v", err)
+	} else if requests >= passwordResetMaxRequests() {
+		if err := database.LockLogin(throttleKey, time.Now().Add(passwordResetTTL())); err != nil {
+			log.Printf("Failed to throttle password reset requests: %v", err)
+		}
+	}
+
+	response := gin.H{"message": "If the account exists and has an email address, a reset link has been sent"}
+
+	var adminID uint
+	var username, email string
+	err = database.DB.QueryRow(`SELECT id, username, email FROM admin
+		WHERE COALESCE(is_active, TRUE) AND COALESCE(email, '') <> ''
+		AND (($1 <> '' AND LOWER(email) = LOWER($1)) OR ($2 <> '' AND username = $2))
+		ORDER BY id LIMIT 1`, req.Email, req.Username).Scan(&adminID, &username, &email)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Failed to look up account for password reset: %v", err)
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	token, tokenHash, err := middleware.GenerateRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	ttl := passwordResetTTL()
+	if err := database.CreatePasswordResetToken(adminID, tokenHash, c.ClientIP(), time.Now().Add(ttl)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token"})
+		return
+	}
+
+	msg := mailer.Message{
+		To:      email,
+		Subject: "Đặt lại mật khẩu / Password reset",
+		Body: fmt.Sprintf("Xin chào %s,\n\n"+
+			"Mở liên kết sau để đặt lại mật khẩu (hiệu lực %s, chỉ dùng được một lần):\n%s\n\n"+
+			"Open the link above to reset your password. It expires in %s and can only be used once.\n\n"+
+			"Nếu bạn không yêu cầu đặt lại mật khẩu, hãy bỏ qua email này.\n",
+			username, ttl, passwordResetURL(token), ttl),
+	}
+	// Send in the background so the response time does not reveal whether the account exists
+	go func() {
+		if err := mailer.Send(msg); err != nil {
+			log.Printf("Failed to send password reset email to admin %d: %v", adminID, err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword and logs out every session
+func ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	adminID, err := database.ConsumePasswordResetToken(tx, middleware.HashToken(req.Token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var username string
+	if err := tx.QueryRow("SELECT username FROM admin WHERE id = $1", adminID).Scan(&username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	// A weak password rolls back the transaction, so the token stays usable for another try
+	if err := password.Validate(req.NewPassword, username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+	if err := database.SetAdminPassword(tx, adminID, string(hashedPassword)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+	if _, err := database.RevokeAllSessions(tx, adminID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	// The owner of the mailbox proved control of the account, lift any login lockout
+	clearLoginFailures(username)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please log in"})
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"house-design-backend/database"
+	"house-design-backend/mergepatch"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fieldErrors maps JSON field names to what is wrong with their value
+type fieldErrors map[string]string
+
+func respondFieldErrors(c *gin.Context, errs fieldErrors) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fields", "fields": errs})
+}
+
+// requireFields reports the fields whose value is blank
+func requireFields(errs fieldErrors, values map[string]string) {
+	for name, value := range values {
+		if strings.TrimSpace(value) == "" {
+			errs[name] = "is required"
+		}
+	}
+}
+
+// Fields the server maintains, which a patch may not change
+var (
+	postReadOnlyFields      = []string{"id", "category", "views", "tags", "author_id", "author", "last_edited_by", "content_sanitized", "version", "created_at", "updated_at"}
+	singletonReadOnlyFields = []string{"id", "created_at", "updated_at"}
+)
+
+// applyMergePatch applies the JSON Merge Patch (RFC 7386) in the request body to current and
+// decodes the result into target, a pointer to a value of the same type. A null member
+// removes the field, leaving its zero value in target. Unknown and read-only fields and
+// values of the wrong type are all reported at once. It responds and returns false when the
+// patch cannot be applied.
+func applyMergePatch(c *gin.Context, current, target interface{}, readOnly ...string) bool {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return false
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body must be a JSON object"})
+		return false
+	}
+
+	targetType := reflect.TypeOf(target).Elem()
+	fields := jsonFields(targetType)
+	errs := fieldErrors{}
+	for name, value := range patch {
+		fieldType, known := fields[name]
+		switch {
+		case containsString(readOnly, name):
+			errs[name] = "is read-only"
+		case !known:
+			errs[name] = "is not a known field"
+		case string(value) != "null":
+			// Each member is decoded on its own so every invalid field is reported
+			member, _ := json.Marshal(map[string]json.RawMessage{name: value})
+			if err := json.Unmarshal(member, reflect.New(targetType).Interface()); err != nil {
+				errs[name] = "must be " + jsonTypeName(fieldType)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return false
+	}
+
+	document, err := json.Marshal(current)
+	if err == nil {
+		var merged []byte
+		if merged, err = mergepatch.Apply(document, body); err == nil {
+			err = json.Unmarshal(merged, target)
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply patch"})
+		return false
+	}
+	return true
+}
+
+// jsonFields maps the JSON names of a struct's fields to their types
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// jsonTypeName describes the JSON value a Go type decodes from
+func jsonTypeName(t reflect.Type) string {
+	if t == reflect.TypeOf(time.Time{}) {
+		return "an RFC 3339 timestamp"
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return jsonTypeName(t.Elem())
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a non-negative integer"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array of " + strings.TrimPrefix(strings.TrimPrefix(jsonTypeName(t.Elem()), "an "), "a ") + "s"
+	default:
+		return "an object"
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// PatchPost updates only the fields present in a JSON Merge Patch. A null clears the field;
+// for tag_ids it removes every tag. title, category_id and slug cannot be cleared.
+func PatchPost(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+	ifMatch, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	current, err := loadPost([]string{"p.id = $1", livePostCondition}, postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch post"})
+		return
+	}
+	// Checked before the patch is applied so that a stale patch is not validated against
+	// fields it never saw. updatePost checks again under the row lock against the version
+	// the patch was applied to, so a concurrent edit is not overwritten even with If-Match: *
+	if !ifMatchVersion(ifMatch, current.Version) {
+		respondVersionConflict(c, current.Version, current)
+		return
+	}
+	tagIDs := make([]uint, 0, len(current.Tags))
+	for _, tag := range current.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	current.TagIDs = &tagIDs
+
+	var post models.Post
+	if !applyMergePatch(c, current, &post, postReadOnlyFields...) {
+		return
+	}
+	if post.TagIDs == nil {
+		post.TagIDs = &[]uint{}
+	}
+
+	errs := fieldErrors{}
+	requireFields(errs, map[string]string{"title": post.Title, "slug": post.Slug})
+	if post.CategoryID == 0 {
+		errs["category_id"] = "is required"
+	}
+	if err := validatePostSchedule(&post); err != nil {
+		errs["unpublish_at"] = "must be after publish_at"
+	}
+	if len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
+	updatePost(c, uint(postID), post, versionETag(current.Version))
+}
+
+// PatchHomeContent updates only the fields of the home content present in a JSON Merge Patch
+func PatchHomeContent(c *gin.Context) {
+	current, err := loadHomeContent()
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Home content not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch home content"})
+		}
+		return
+	}
+
+	var updateData models.HomeContent
+	if !applyMergePatch(c, current, &updateData, singletonReadOnlyFields...) {
+		return
+	}
+
+	errs := fieldErrors{}
+	requireFields(errs, map[string]string{"hero_title": updateData.HeroTitle})
+	if len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
+	if err := saveHomeContent(updateData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update home content"})
+		return
+	}
+
+	GetHomeContent(c)
+}
+
+// PatchFooterContent updates only the fields of the footer content present in a JSON Merge
+// Patch. services and social_media are replaced as a whole, null empties them.
+func PatchFooterContent(c *gin.Context) {
+	current, err := loadFooterContent()
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Footer content not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch footer content"})
+		}
+		return
+	}
+
+	var updateData FooterContentResponse
+	if !applyMergePatch(c, current, &updateData, singletonReadOnlyFields...) {
+		return
+	}
+
+	errs := fieldErrors{}
+	requireFields(errs, map[string]string{"company_name": updateData.CompanyName})
+	if len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
+	if err := saveFooterContent(updateData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update footer content"})
+		return
+	}
+
+	GetFooterContent(c)
+}
+
+// PatchGlobalSEOSettings updates only the SEO settings present in a JSON Merge Patch. Before
+// the settings are first saved the patch applies to the defaults.
+func PatchGlobalSEOSettings(c *gin.Context) {
+	existing, err := database.GetGlobalSEOSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch existing settings"})
+		return
+	}
+	current := defaultGlobalSEOSettings()
+	if existing != nil {
+		current = *existing
+	}
+
+	var updateData models.GlobalSEOSettings
+	if !applyMergePatch(c, current, &updateData, singletonReadOnlyFields...) {
+		return
+	}
+	updateData.ID = current.ID
+
+	errs := fieldErrors{}
+	requireFields(errs, map[string]string{
+		"site_name":                updateData.SiteName,
+		"default_meta_title":       updateData.DefaultMetaTitle,
+		"default_meta_description": updateData.DefaultMetaDescription,
+		"company_name":             updateData.CompanyName,
+	})
+	if len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
+	if err := database.UpdateGlobalSEOSettings(&updateData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update SEO settings"})
+		return
+	}
+
+	GetGlobalSEOSettings(c)
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"house-design-backend/database"
+	"house-design-backend/middleware"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// maxBulkPosts bounds how many posts one bulk request may change
+const maxBulkPosts = 500
+
+// bulkPostUpdates are the column changes of the bulk actions that update posts. Publishing
+// and unpublishing cancel a pending scheduled publish, which would otherwise undo them.
+var bulkPostUpdates = map[string]string{
+	"publish":     "published = TRUE, publish_at = NULL",
+	"unpublish":   "published = FALSE, publish_at = NULL",
+	"move":        "category_id = $3",
+	"add_tags":    "",
+	"remove_tags": "",
+}
+
+// BulkPosts applies one action to a list of posts in a single transaction and reports the
+// outcome per post. Each post runs in its own savepoint, so a failure only undoes that post
+// unless the request is atomic, in which case every change is rolled back and the response
+// is 409 with the results that would have been.
+func BulkPosts(c *gin.Context) {
+	var req models.BulkPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := bulkPostUpdates[req.Action]; !ok && req.Action != "delete" {
+		respondFieldErrors(c, fieldErrors{"action": "must be publish, unpublish, move, add_tags, remove_tags or delete"})
+		return
+	}
+	if req.Action == "delete" && !middleware.CheckPermission(c, middleware.PermDeletePosts) {
+		return
+	}
+
+	ids := uniqueIDs(req.IDs)
+	errs := fieldErrors{}
+	if len(ids) == 0 {
+		errs["ids"] = "is required"
+	} else if len(ids) > maxBulkPosts {
+		errs["ids"] = fmt.Sprintf("must not list more than %d posts", maxBulkPosts)
+	}
+	if req.Action == "move" {
+		if req.CategoryID == 0 {
+			errs["category_id"] = "is required"
+		} else if exists, err := rowExists("SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1 AND deleted_at IS NULL)", req.CategoryID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check category"})
+			return
+		} else if !exists {
+			errs["category_id"] = "does not exist"
+		}
+	}
+	tagIDs := uniqueIDs(req.TagIDs)
+	if req.Action == "add_tags" || req.Action == "remove_tags" {
+		var found int
+		if len(tagIDs) == 0 {
+			errs["tag_ids"] = "is required"
+		} else if err := database.DB.QueryRow("SELECT COUNT(*) FROM tags WHERE id = ANY($1::INTEGER[])",
+			pq.Array(tagIDs)).Scan(&found); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check tags"})
+			return
+		} else if found != len(tagIDs) {
+			errs["tag_ids"] = "lists tags that do not exist"
+		}
+	}
+	if len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	response := models.BulkPostResponse{Action: req.Action, Results: make([]models.BulkPostResult, 0, len(ids))}
+	for _, id := range ids {
+		result := models.BulkPostResult{ID: uint(id), Status: "ok"}
+		if _, err := tx.Exec("SAVEPOINT bulk_post"); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update posts"})
+			return
+		}
+
+		found, err := applyBulkPostAction(tx, req.Action, id, req.CategoryID, tagIDs, c.GetUint("user_id"))
+		switch {
+		case err != nil && req.Action == "delete":
+			result.Status, result.Error = "failed", "Failed to delete post"
+		case err != nil:
+			result.Status, result.Error = "failed", "Failed to update post"
+		case !found:
+			result.Status, result.Error = "not_found", "Post not found"
+		}
+
+		release := "RELEASE SAVEPOINT bulk_post"
+		if result.Status != "ok" {
+			release = "ROLLBACK TO SAVEPOINT bulk_post"
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+		if _, err := tx.Exec(release); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update posts"})
+			return
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	if req.Atomic && response.Failed > 0 {
+		tx.Rollback()
+		response.RolledBack = true
+		for i := range response.Results {
+			if response.Results[i].Status == "ok" {
+				response.Results[i].Status = "rolled_back"
+			}
+		}
+		response.Succeeded = 0
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// applyBulkPostAction applies a bulk action to one post and reports whether the post exists.
+// delete moves the post to the trash. The other actions count as an edit: they bump the
+// post's version so that editors holding an older copy get 412 on their next save.
+func applyBulkPostAction(tx *sql.Tx, action string, postID int64, categoryID uint, tagIDs []int64, userID uint) (bool, error) {
+	if action == "delete" {
+		return trashPost(tx, postID)
+	}
+
+	set := "last_edited_by = NULLIF($2, 0), version = version + 1, updated_at = CURRENT_TIMESTAMP"
+	if columns := bulkPostUpdates[action]; columns != "" {
+		set = columns + ", " + set
+	}
+	args := []interface{}{postID, userID}
+	if action == "move" {
+		args = append(args, categoryID)
+	}
+	result, err := tx.Exec("UPDATE posts SET "+set+" WHERE id = $1 AND deleted_at IS NULL", args...)
+	if err != nil {
+		return false, err
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		return false, nil
+	}
+
+	switch action {
+	case "add_tags":
+		_, err = tx.Exec(`INSERT INTO post_tags (post_id, tag_id)
+			SELECT $1, id FROM tags WHERE id = ANY($2::INTEGER[])
+			ON CONFLICT DO NOTHING`, postID, pq.Array(tagIDs))
+	case "remove_tags":
+		_, err = tx.Exec("DELETE FROM post_tags WHERE post_id = $1 AND tag_id = ANY($2::INTEGER[])", postID, pq.Array(tagIDs))
+	}
+	return true, err
+}
+
+// uniqueIDs drops duplicate IDs, keeping the first occurrence, as int64 for pq.Array
+func uniqueIDs(ids []uint) []int64 {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, int64(id))
+		}
+	}
+	return unique
+}
+
+func rowExists(query string, args ...interface{}) (bool, error) {
+	var exists bool
+	err := database.DB.QueryRow(query, args...).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+
+	"house-design-backend/mdimport"
+	"house-design-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxImportFiles bounds how many Markdown files one import request may carry
+	maxImportFiles = 50
+	// maxImportSize bounds a whole import request, Markdown files and images together
+	maxImportSize = 100 * 1024 * 1024
+)
+
+// ImportMarkdownPosts creates or updates posts from Markdown files with YAML front matter,
+// uploaded as files. The local images they reference are uploaded alongside as images and
+// matched by file name, which needs the media:upload permission. category is the category
+// slug for files whose front matter has none. Tags that do not exist yet are only created
+// with the categories:manage permission, otherwise the files using them fail. Each file is
+// imported on its own and reported in results.
+func ImportMarkdownPosts(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	form, err := c.MultipartForm()
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "The import must not be larger than 100MB, split it into several requests"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expected a multipart form with Markdown files"})
+		return
+	}
+	files := form.File["files"]
+	if len(files) == 0 {
+		respondFieldErrors(c, fieldErrors{"files": "is required"})
+		return
+	}
+	if len(files) > maxImportFiles {
+		respondFieldErrors(c, fieldErrors{"files": fmt.Sprintf("must not list more than %d files", maxImportFiles)})
+		return
+	}
+
+	if len(form.File["images"]) > 0 && !middleware.CheckPermission(c, middleware.PermUploadMedia) {
+		return
+	}
+	images := map[string]*multipart.FileHeader{}
+	for _, image := range form.File["images"] {
+		images[image.Filename] = image
+	}
+	readImage := func(ref string) ([]byte, error) {
+		image, ok := images[path.Base(ref)]
+		if !ok {
+			return nil, errors.New("not among the uploaded images")
+		}
+		data, err := readFormFile(image, mdimport.MaxImageSize)
+		if errors.Is(err, errFileTooLarge) {
+			return nil, errors.New("larger than 5MB")
+		}
+		return data, err
+	}
+
+	opts := mdimport.Options{
+		DefaultCategory: strings.TrimSpace(c.PostForm("category")),
+		UserID:          c.GetUint("user_id"),
+		CreateTags:      middleware.Allows(c, middleware.PermManageCategories),
+	}
+	results := make([]mdimport.Result, 0, len(files))
+	counts := map[string]int{"created": 0, "updated": 0, "unchanged": 0, "failed": 0}
+	for _, file := range files {
+		var result mdimport.Result
+		if source, err := readFormFile(file, mdimport.MaxFileSize); errors.Is(err, errFileTooLarge) {
+			result = mdimport.Result{File: file.Filename, Status: "failed", Error: "File is larger than 1MB"}
+		} else if err != nil {
+			result = mdimport.Result{File: file.Filename, Status: "failed", Error: "Failed to read file"}
+		} else {
+			result = mdimport.Import(file.Filename, source, readImage, opts)
+		}
+		if len(result.UnknownTags) > 0 {
+			result.Error += ", creating tags requires the categories:manage permission"
+		}
+		counts[result.Status]++
+		results = append(results, result)
+	}
+
+	response := gin.H{"results": results}
+	for status, count := range counts {
+		response[status] = count
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+var errFileTooLarge = errors.New("larger than allowed")
+
+// readFormFile reads an uploaded file, failing with errFileTooLarge past limit bytes
+func readFormFile(header *multipart.FileHeader, limit int64) ([]byte, error) {
+	if header.Size > limit {
+		return nil, errFileTooLarge
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err == nil && int64(len(data)) > limit {
+		return nil, errFileTooLarge
+	}
+	return data, err
+}
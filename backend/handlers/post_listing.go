@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"house-design-backend/database"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPostPageSize = 20
+	maxPostPageSize     = 100
+)
+
+// postSort describes one ?sort= option. The id column breaks ties so that keyset
+// pagination never skips or repeats a post.
+type postSort struct {
+	column     string
+	descending bool
+	// cursorValue extracts the sort key of a post for the next cursor
+	cursorValue func(post models.Post) interface{}
+}
+
+var postSorts = map[string]postSort{
+	"newest":      {"p.created_at", true, func(post models.Post) interface{} { return post.CreatedAt }},
+	"oldest":      {"p.created_at", false, func(post models.Post) interface{} { return post.CreatedAt }},
+	"most_viewed": {"COALESCE(p.views, 0)", true, func(post models.Post) interface{} { return post.Views }},
+	"title":       {"p.title", false, func(post models.Post) interface{} { return post.Title }},
+}
+
+// postCursor is the position after the last post of a page, sent to clients as opaque base64
+type postCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"id"`
+}
+
+func encodePostCursor(sortName string, sort postSort, post models.Post) string {
+	value, _ := json.Marshal(sort.cursorValue(post))
+	data, _ := json.Marshal(postCursor{Sort: sortName, Value: value, ID: post.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePostCursor parses a cursor and returns its sort key as a query argument
+func decodePostCursor(raw, sortName string) (interface{}, uint, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	var cursor postCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, 0, err
+	}
+	if cursor.Sort != sortName {
+		return nil, 0, fmt.Errorf("cursor was created for sort %q", cursor.Sort)
+	}
+
+	switch sortName {
+	case "newest", "oldest":
+		var value time.Time
+		err = json.Unmarshal(cursor.Value, &value)
+		return value, cursor.ID, err
+	case "most_viewed":
+		var value int
+		err = json.Unmarshal(cursor.Value, &value)
+		return value, cursor.ID, err
+	default:
+		var value string
+		err = json.Unmarshal(cursor.Value, &value)
+		return value, cursor.ID, err
+	}
+}
+
+// parseDateParam accepts YYYY-MM-DD or RFC 3339. A bare date used as an upper bound covers the whole day.
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// listPosts writes one page of posts matching conditions. It supports:
+//
+//	?category=ID               posts of a category
+//	?include_children=true     together with category, also posts of its descendant categories
+//	?tag=                      posts carrying a tag, by slug or ID
+//	?author=ID                 posts written by a user
+//	?from=, ?to=               created_at range, YYYY-MM-DD or RFC 3339
+//	?sort=                     newest (default), oldest, most_viewed or title
+//	?limit=, ?offset=          page size (default 20, max 100) and offset
+//	?cursor=                   next_cursor of the previous page, replaces offset
+//	?view=list                 omit the post content
+func listPosts(c *gin.Context, conditions []string, args []interface{}) {
+	if response, ok := queryPosts(c, conditions, args); ok {
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// queryPosts loads the page of posts listPosts writes. On failure it writes the error
+// response itself and returns false.
+func queryPosts(c *gin.Context, conditions []string, args []interface{}) (*models.PostListResponse, bool) {
+	if categoryID := c.Query("category"); categoryID != "" {
+		id, err := strconv.Atoi(categoryID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
+			return nil, false
+		}
+		args = append(args, id)
+		if c.Query("include_children") == "true" {
+			conditions = append(conditions, fmt.Sprintf(`p.category_id IN (
+				WITH RECURSIVE tree AS (
+					SELECT id FROM categories WHERE id = $%d
+					UNION ALL
+					SELECT child.id FROM categories child JOIN tree ON child.parent_id = tree.id
+				) SELECT id FROM tree)`, len(args)))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("p.category_id = $%d", len(args)))
+		}
+	}
+
+	if authorID := c.Query("author"); authorID != "" {
+		id, err := strconv.Atoi(authorID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author"})
+			return nil, false
+		}
+		args = append(args, id)
+		conditions = append(conditions, fmt.Sprintf("p.author_id = $%d", len(args)))
+	}
+
+	if tag := c.Query("tag"); tag != "" {
+		column := "t.slug"
+		if _, err := strconv.ParseUint(tag, 10, 32); err == nil {
+			column = "t.id::TEXT"
+		}
+		args = append(args, tag)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM post_tags pt JOIN tags t ON t.id = pt.tag_id
+			WHERE pt.post_id = p.id AND %s = $%d)`, column, len(args)))
+	}
+
+	for _, bound := range []struct {
+		param    string
+		operator string
+		endOfDay bool
+	}{
+		{"from", ">=", false},
+		{"to", "<", true},
+	} {
+		value := c.Query(bound.param)
+		if value == "" {
+			continue
+		}
+		t, err := parseDateParam(value, bound.endOfDay)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + bound.param + " date, use YYYY-MM-DD or RFC 3339"})
+			return nil, false
+		}
+		args = append(args, t)
+		conditions = append(conditions, fmt.Sprintf("p.created_at %s $%d", bound.operator, len(args)))
+	}
+
+	sortName := c.DefaultQuery("sort", "newest")
+	sort, ok := postSorts[sortName]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort, use newest, oldest, most_viewed or title"})
+		return nil, false
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPostPageSize)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return nil, false
+	}
+	if limit > maxPostPageSize {
+		limit = maxPostPageSize
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return nil, false
+	}
+
+	from := ` FROM posts p JOIN categories c ON p.category_id = c.id` + postAuthorJoin
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// The total ignores the cursor so it stays the size of the whole result set
+	var total int
+	if err := database.DB.QueryRow("SELECT COUNT(*)"+from+where, args...).Scan(&total); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count posts"})
+		return nil, false
+	}
+
+	direction, comparison := "ASC", ">"
+	if sort.descending {
+		direction, comparison = "DESC", "<"
+	}
+	if rawCursor := c.Query("cursor"); rawCursor != "" {
+		value, id, err := decodePostCursor(rawCursor, sortName)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return nil, false
+		}
+		args = append(args, value, id)
+		cursorCondition := fmt.Sprintf("(%s, p.id) %s ($%d, $%d)", sort.column, comparison, len(args)-1, len(args))
+		if where == "" {
+			where = " WHERE " + cursorCondition
+		} else {
+			where += " AND " + cursorCondition
+		}
+		offset = 0
+	}
+
+	listView := c.Query("view") == "list"
+	contentColumn := "p.content"
+	if listView {
+		contentColumn = "''"
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	args = append(args, limit+1, offset)
+	query := `SELECT p.id, p.title, ` + contentColumn + `, p.summary, p.image_url, p.category_id,
+			  p.published, COALESCE(p.views, 0), p.created_at, p.updated_at,
+			  COALESCE(p.meta_title, '') as meta_title, COALESCE(p.meta_description, '') as meta_description,
+			  COALESCE(p.focus_keywords, '') as focus_keywords, COALESCE(p.og_image_url, '') as og_image_url, COALESCE(p.canonical_url, '') as canonical_url, COALESCE(p.slug, '') as slug,
+			  p.publish_at, p.unpublish_at, p.version, ` + postAuthorColumns + `, c.name, c.slug, c.description` + from + where +
+		fmt.Sprintf(" ORDER BY %s %s, p.id %s LIMIT $%d OFFSET $%d", sort.column, direction, direction, len(args)-1, len(args))
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		return nil, false
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		var post models.Post
+		var category models.Category
+		var author models.Author
+
+		dest := []interface{}{&post.ID, &post.Title, &post.Content, &post.Summary, &post.ImageURL,
+			&post.CategoryID, &post.Published, &post.Views, &post.CreatedAt, &post.UpdatedAt,
+			&post.MetaTitle, &post.MetaDescription, &post.FocusKeywords, &post.OGImageURL, &post.CanonicalURL, &post.Slug,
+			&post.PublishAt, &post.UnpublishAt, &post.Version}
+		dest = append(dest, postAuthorDest(&post, &author)...)
+		err := rows.Scan(append(dest, &category.Name, &category.Slug, &category.Description)...)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan post"})
+			return nil, false
+		}
+
+		category.ID = post.CategoryID
+		post.Category = category
+		setPostAuthor(&post, author)
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		return nil, false
+	}
+
+	response := models.PostListResponse{Total: total, Limit: limit, Offset: offset}
+	if len(posts) > limit {
+		posts = posts[:limit]
+		response.NextCursor = encodePostCursor(sortName, sort, posts[len(posts)-1])
+	}
+	if err := loadPostTags(posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return nil, false
+	}
+	response.Posts = posts
+
+	return &response, true
+}
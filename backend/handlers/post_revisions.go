@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"house-design-backend/database"
+	"house-design-backend/diff"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const postRevisionColumns = `r.id, r.post_id, r.revision, r.title, r.summary, r.image_url, r.meta_title, r.meta_description,
+	r.focus_keywords, r.og_image_url, r.editor_id, COALESCE(a.username, ''), r.restored_from, r.created_at`
+
+func scanPostRevision(row interface{ Scan(...interface{}) error }, revision *models.PostRevision, extra ...interface{}) error {
+	dest := []interface{}{&revision.ID, &revision.PostID, &revision.Revision, &revision.Title, &revision.Summary,
+		&revision.ImageURL, &revision.MetaTitle, &revision.MetaDescription, &revision.FocusKeywords, &revision.OGImageURL,
+		&revision.EditorID, &revision.EditorUsername, &revision.RestoredFrom, &revision.CreatedAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// getPostRevision loads one revision including its content
+func getPostRevision(postID uint64, number int) (models.PostRevision, error) {
+	var revision models.PostRevision
+	row := database.DB.QueryRow(`SELECT `+postRevisionColumns+`, r.content
+		FROM post_revisions r LEFT JOIN admin a ON a.id = r.editor_id
+		WHERE r.post_id = $1 AND r.revision = $2`, postID, number)
+	err := scanPostRevision(row, &revision, &revision.Content)
+	return revision, err
+}
+
+// savePostRevision records the state a post was just saved in and drops revisions beyond the
+// retention limit. It must run in the transaction that changed the post.
+func savePostRevision(tx *sql.Tx, postID, editorID uint, restoredFrom int) error {
+	if err := database.SavePostRevision(tx, postID, editorID, restoredFrom); err != nil {
+		return err
+	}
+	return database.PrunePostRevisions(tx, postID, database.PostRevisionLimit())
+}
+
+// lockPostForUpdate takes the row lock that serializes saves of one post, so revision numbers
+// do not collide, records a baseline revision for posts written before revisions existed and
+// returns the post's current version
+func lockPostForUpdate(tx *sql.Tx, postID uint) (int, error) {
+	var version int
+	if err := tx.QueryRow("SELECT version FROM posts WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", postID).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, database.SaveBaselinePostRevision(tx, postID)
+}
+
+// GetPostRevisions lists the revisions of a post, newest first, without their content
+func GetPostRevisions(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	rows, err := database.DB.Query(`SELECT `+postRevisionColumns+`
+		FROM post_revisions r LEFT JOIN admin a ON a.id = r.editor_id
+		WHERE r.post_id = $1 ORDER BY r.revision DESC`, postID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+		return
+	}
+	defer rows.Close()
+
+	revisions := []models.PostRevision{}
+	for rows.Next() {
+		var revision models.PostRevision
+		if err := scanPostRevision(rows, &revision); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan revision"})
+			return
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetPostRevision returns one revision of a post including its content
+func GetPostRevision(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+	number, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision"})
+		return
+	}
+
+	revision, err := getPostRevision(postID, number)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revision"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// GetPostRevisionDiff compares two revisions line by line: ?from= and ?to= are revision numbers,
+// to defaulting to the latest revision and from to the one before to
+func GetPostRevisionDiff(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var to, from int
+	if value := c.Query("to"); value != "" {
+		if to, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to revision"})
+			return
+		}
+	} else if err := database.DB.QueryRow("SELECT COALESCE(MAX(revision), 0) FROM post_revisions WHERE post_id = $1", postID).Scan(&to); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+		return
+	}
+	if value := c.Query("from"); value != "" {
+		if from, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from revision"})
+			return
+		}
+	} else if err := database.DB.QueryRow("SELECT COALESCE(MAX(revision), 0) FROM post_revisions WHERE post_id = $1 AND revision < $2",
+		postID, to).Scan(&from); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+		return
+	}
+
+	fromRevision, err := getPostRevision(postID, from)
+	if err == nil {
+		var toRevision models.PostRevision
+		if toRevision, err = getPostRevision(postID, to); err == nil {
+			c.JSON(http.StatusOK, diffPostRevisions(fromRevision, toRevision))
+			return
+		}
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revision"})
+}
+
+// diffPostRevisions lists the fields that differ between two revisions. The returned
+// revisions leave out their content, which the diff already carries.
+func diffPostRevisions(from, to models.PostRevision) models.PostRevisionDiff {
+	result := models.PostRevisionDiff{PostID: from.PostID, Fields: []models.PostRevisionFieldDiff{}}
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"title", from.Title, to.Title},
+		{"summary", from.Summary, to.Summary},
+		{"content", from.Content, to.Content},
+		{"image_url", from.ImageURL, to.ImageURL},
+		{"meta_title", from.MetaTitle, to.MetaTitle},
+		{"meta_description", from.MetaDescription, to.MetaDescription},
+		{"focus_keywords", from.FocusKeywords, to.FocusKeywords},
+		{"og_image_url", from.OGImageURL, to.OGImageURL},
+	}
+	for _, field := range fields {
+		if field.from == field.to {
+			continue
+		}
+		var chunks []diff.Chunk
+		if field.name == "content" {
+			chunks = diff.Slices(htmlLines(field.from), htmlLines(field.to))
+		} else {
+			chunks = diff.Lines(field.from, field.to)
+		}
+		result.Fields = append(result.Fields, models.PostRevisionFieldDiff{Field: field.name, Chunks: chunks})
+	}
+
+	from.Content, to.Content = "", ""
+	result.From, result.To = from, to
+	return result
+}
+
+// blockEnd matches the end of the HTML blocks the editor produces
+var blockEnd = regexp.MustCompile(`(?i)(</(p|h[1-6]|li|ul|ol|blockquote|figure|figcaption|table|tr|div|pre)>|<br\s*/?>)`)
+
+// htmlLines splits editor HTML into one line per block, since it is often saved on one line
+func htmlLines(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(blockEnd.ReplaceAllString(content, "$1\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// RestorePostRevision copies an older revision back into the post and records the result
+// as a new revision, so the restore itself can be undone. Like UpdatePost it requires the
+// post's current ETag in If-Match.
+func RestorePostRevision(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+	number, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision"})
+		return
+	}
+	ifMatch, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	version, err := lockPostForUpdate(tx, uint(postID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock post"})
+		return
+	}
+	if !ifMatchVersion(ifMatch, version) {
+		tx.Rollback()
+		respondPostConflict(c, uint(postID))
+		return
+	}
+
+	result, err := tx.Exec(`UPDATE posts p SET title = r.title, summary = r.summary, content = r.content,
+			image_url = r.image_url, meta_title = r.meta_title, meta_description = r.meta_description,
+			focus_keywords = r.focus_keywords, og_image_url = r.og_image_url, last_edited_by = $3,
+			version = p.version + 1, updated_at = CURRENT_TIMESTAMP
+		FROM post_revisions r
+		WHERE p.id = $1 AND r.post_id = p.id AND r.revision = $2`, postID, number, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore revision"})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+	// Revisions keep content as it was saved, which may predate the current sanitize policy
+	if _, err := database.SanitizePostContent(tx, uint(postID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sanitize content"})
+		return
+	}
+
+	if err := savePostRevision(tx, uint(postID), c.GetUint("user_id"), number); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save revision"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	getPost(c, []string{"p.id = $1", livePostCondition}, postID)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"house-design-backend/database"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validatePostSchedule checks that a post is not taken down before it goes live. A publish_at
+// that has already passed on a post saved unpublished is dropped, since the public queries and
+// the scheduler would otherwise publish the post again right away: unpublishing wins.
+func validatePostSchedule(post *models.Post) error {
+	if !post.Published && post.PublishAt != nil && !post.PublishAt.After(time.Now()) {
+		post.PublishAt = nil
+	}
+	if post.PublishAt != nil && post.UnpublishAt != nil && !post.UnpublishAt.After(*post.PublishAt) {
+		return errors.New("unpublish_at must be after publish_at")
+	}
+	return nil
+}
+
+// GetScheduledPosts lists the pending publish and unpublish changes, soonest first.
+// Changes whose time has passed but that the scheduler has not applied yet are included.
+// ?limit= defaults to 50 with a maximum of 200.
+func GetScheduledPosts(c *gin.Context) {
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		if parsed < 200 {
+			limit = parsed
+		} else {
+			limit = 200
+		}
+	}
+
+	rows, err := database.DB.Query(`SELECT id, title, slug, published, action, at FROM (
+			SELECT id, title, slug, COALESCE(published, FALSE) AS published, 'publish' AS action, publish_at AS at
+			FROM posts WHERE publish_at IS NOT NULL AND deleted_at IS NULL
+			UNION ALL
+			SELECT id, title, slug, COALESCE(published, FALSE), 'unpublish', unpublish_at
+			FROM posts WHERE unpublish_at IS NOT NULL AND deleted_at IS NULL
+		) changes
+		ORDER BY at ASC, id ASC
+		LIMIT $1`, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scheduled posts"})
+		return
+	}
+	defer rows.Close()
+
+	changes := []models.ScheduledPostChange{}
+	for rows.Next() {
+		var change models.ScheduledPostChange
+		if err := rows.Scan(&change.PostID, &change.Title, &change.Slug, &change.Published, &change.Action, &change.At); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan scheduled post"})
+			return
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scheduled posts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
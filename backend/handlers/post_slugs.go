@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"house-design-backend/database"
+	"house-design-backend/slug"
+)
+
+// uniquePostSlug turns the requested slug, or the title when it is empty, into a slug that no
+// other post uses, appending -2, -3... on conflict like CreateCategory does. excludeID is the
+// post being updated, 0 when creating.
+func uniquePostSlug(requested, title string, excludeID uint) (string, error) {
+	return uniqueSlug("posts", requested, title, "post", excludeID)
+}
+
+// uniqueSlug is uniquePostSlug for any table with id and slug columns. table is never user input.
+func uniqueSlug(table, requested, name, fallback string, excludeID uint) (string, error) {
+	base := slug.Make(requested)
+	if base == "" {
+		base = slug.Make(name)
+	}
+	if base == "" {
+		base = fallback
+	}
+
+	candidate := base
+	for counter := 2; ; counter++ {
+		var exists bool
+		err := database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM "+table+" WHERE slug = $1 AND id <> $2)", candidate, excludeID).Scan(&exists)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = slug.WithSuffix(base, counter)
+	}
+}
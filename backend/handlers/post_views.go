@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"house-design-backend/database"
+	"house-design-backend/viewcounter"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordPostView counts a visit to a public post. Bots and repeat visits are ignored and
+// reported with counted=false; counts reach posts.views with the next batched flush.
+func RecordPostView(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var visible bool
+	err = database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM posts p JOIN categories c ON p.category_id = c.id
+		WHERE p.id = $1 AND `+strings.Join(publicPostConditions, " AND ")+`)`, postID).Scan(&visible)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch post"})
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	counted := viewcounter.Record(uint(postID), c.ClientIP(), c.Request.UserAgent())
+	c.JSON(http.StatusAccepted, gin.H{"counted": counted})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"house-design-backend/database"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	defaultSearchPageSize = 10
+	maxSearchPageSize     = 50
+	maxSearchQueryLength  = 200
+
+	// ts_headline marks matches with these; they are turned into <mark> after HTML escaping
+	highlightStart = "⟦"
+	highlightStop  = "⟧"
+)
+
+var highlightOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s"`, highlightStart, highlightStop)
+
+// renderHighlight escapes text for HTML and turns the ts_headline markers into <mark> tags
+func renderHighlight(text string) string {
+	text = html.EscapeString(strings.Join(strings.Fields(text), " "))
+	return strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>").Replace(text)
+}
+
+// SearchPosts runs a full-text search over the published posts.
+// Supports ?q= (required), ?category=, ?limit= (default 10, max 50) and ?offset=.
+func SearchPosts(c *gin.Context) {
+	// Vietnamese text may arrive decomposed (NFD) depending on the keyboard
+	q := strings.TrimSpace(norm.NFC.String(c.Query("q")))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+		return
+	}
+	if utf8.RuneCountInString(q) > maxSearchQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Query must be at most %d characters", maxSearchQueryLength)})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSearchPageSize)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	if limit > maxSearchPageSize {
+		limit = maxSearchPageSize
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+
+	from := fmt.Sprintf(` FROM posts p JOIN categories c ON p.category_id = c.id,
+		websearch_to_tsquery('%s', $1) query
+		WHERE p.search_vector @@ query AND `, database.SearchConfig) + strings.Join(publicPostConditions, " AND ")
+	args := []interface{}{q}
+
+	response := models.SearchResponse{Query: q, Limit: limit, Offset: offset, Results: []models.SearchResult{}}
+	response.Facets.Categories = []models.CategoryFacet{}
+
+	facetRows, err := database.DB.Query(`SELECT c.id, c.name, c.slug, COUNT(*)`+from+`
+		GROUP BY c.id, c.name, c.slug ORDER BY COUNT(*) DESC, c.name ASC`, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search posts"})
+		return
+	}
+	defer facetRows.Close()
+	for facetRows.Next() {
+		var facet models.CategoryFacet
+		if err := facetRows.Scan(&facet.ID, &facet.Name, &facet.Slug, &facet.Count); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search posts"})
+			return
+		}
+		response.Facets.Categories = append(response.Facets.Categories, facet)
+		response.Total += facet.Count
+	}
+	if err := facetRows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search posts"})
+		return
+	}
+
+	if categoryID := c.Query("category"); categoryID != "" {
+		id, err := strconv.Atoi(categoryID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
+			return
+		}
+		args = append(args, id)
+		from += fmt.Sprintf(" AND p.category_id = $%d", len(args))
+
+		response.Total = 0
+		for _, facet := range response.Facets.Categories {
+			if facet.ID == uint(id) {
+				response.Total = facet.Count
+			}
+		}
+	}
+
+	// Rank and page first, so the costly headlines are only built for the returned rows
+	args = append(args, limit, offset)
+	rows, err := database.DB.Query(fmt.Sprintf(`SELECT page.id, page.title, page.summary, page.image_url, page.category_id,
+			page.published, page.views, page.created_at, page.updated_at, page.slug, page.category_name, page.category_slug,
+			page.rank,
+			ts_headline('%[1]s', page.title, page.query, '%[2]s, HighlightAll=true'),
+			ts_headline('%[1]s', page.summary || ' ' || html_to_text(page.content), page.query,
+				'%[2]s, MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … "')
+		FROM (
+			SELECT p.id, p.title, COALESCE(p.summary, '') AS summary, COALESCE(p.image_url, '') AS image_url, p.category_id,
+				p.published, COALESCE(p.views, 0) AS views, p.created_at, p.updated_at, COALESCE(p.slug, '') AS slug,
+				c.name AS category_name, c.slug AS category_slug, p.content, query,
+				ts_rank_cd(p.search_vector, query) AS rank`+from+`
+			ORDER BY rank DESC, p.created_at DESC, p.id DESC
+			LIMIT $%[3]d OFFSET $%[4]d
+		) page
+		ORDER BY page.rank DESC, page.created_at DESC, page.id DESC`,
+		database.SearchConfig, highlightOptions, len(args)-1, len(args)), args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search posts"})
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result models.SearchResult
+		post := &result.Post
+		var titleHighlight, snippet string
+		err := rows.Scan(&post.ID, &post.Title, &post.Summary, &post.ImageURL, &post.CategoryID,
+			&post.Published, &post.Views, &post.CreatedAt, &post.UpdatedAt, &post.Slug, &post.Category.Name, &post.Category.Slug,
+			&result.Rank, &titleHighlight, &snippet)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan search result"})
+			return
+		}
+		post.Category.ID = post.CategoryID
+		result.TitleHighlight = renderHighlight(titleHighlight)
+		result.Snippet = renderHighlight(snippet)
+		response.Results = append(response.Results, result)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search posts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
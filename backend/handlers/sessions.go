@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"house-design-backend/database"
+	"house-design-backend/middleware"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// issueTokens opens a new session for admin and returns the access/refresh token pair
+func issueTokens(c *gin.Context, admin models.Admin) (models.LoginResponse, error) {
+	refreshToken, refreshHash, err := middleware.GenerateRefreshToken()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	sessionID, err := database.CreateSession(admin.ID, refreshHash, c.ClientIP(), c.Request.UserAgent(),
+		time.Now().Add(middleware.RefreshTokenTTL()))
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	token, err := middleware.GenerateToken(admin.ID, admin.Username, sessionID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return models.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(middleware.AccessTokenTTL().Seconds()),
+		Admin:        admin,
+	}, nil
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a new refresh token
+func RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	newRefreshToken, newHash, err := middleware.GenerateRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	session, err := database.RotateSession(middleware.HashToken(req.RefreshToken), newHash, c.ClientIP(), c.Request.UserAgent(),
+		time.Now().Add(middleware.RefreshTokenTTL()))
+	if err != nil {
+		switch err {
+		case database.ErrRefreshTokenReused:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token already used, session revoked"})
+		case database.ErrSessionNotFound:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		}
+		return
+	}
+
+	var admin models.Admin
+	row := database.DB.QueryRow("SELECT "+adminUserColumns+" FROM admin WHERE id = $1", session.AdminID)
+	if err := scanAdminUser(row, &admin); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User no longer exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !admin.IsActive {
+		database.RevokeSession(session.ID, admin.ID)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
+
+	token, err := middleware.GenerateToken(admin.ID, admin.Username, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LoginResponse{
+		Token:        token,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int(middleware.AccessTokenTTL().Seconds()),
+		Admin:        admin,
+	})
+}
+
+// Logout revokes the current session. It accepts the refresh token in the body and/or
+// the access token in the Authorization header, so it still works after the access token expired.
+func Logout(c *gin.Context) {
+	var req models.LogoutRequest
+	// The body is optional
+	_ = c.ShouldBindJSON(&req)
+
+	if req.RefreshToken != "" {
+		if err := database.RevokeSessionByRefreshToken(middleware.HashToken(req.RefreshToken)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+	}
+
+	if tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "); tokenString != "" {
+		if claims, err := middleware.ParseToken(tokenString); err == nil {
+			if _, err := database.RevokeSession(claims.SessionID, claims.UserID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll revokes every session of the authenticated user, including the current one
+func LogoutAll(c *gin.Context) {
+	revoked, err := database.RevokeAllSessions(database.DB, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "All sessions logged out successfully",
+		"revoked": revoked,
+	})
+}
+
+// GetSessions lists the active sessions of the authenticated user
+func GetSessions(c *gin.Context) {
+	sessions, err := database.ListActiveSessions(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	currentSessionID := c.GetString("session_id")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeUserSession logs out one session of the authenticated user
+func RevokeUserSession(c *gin.Context) {
+	revoked, err := database.RevokeSession(c.Param("id"), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// GetJWKS publishes the public token verification keys as a JSON Web Key Set
+func GetJWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"keys": middleware.PublicKeys()})
+}
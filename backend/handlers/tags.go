@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"house-design-backend/database"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+const tagColumns = "t.id, t.name, t.slug, t.description, t.created_at, t.updated_at"
+
+func scanTag(row interface{ Scan(...interface{}) error }, tag *models.Tag, extra ...interface{}) error {
+	dest := []interface{}{&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// GetTags lists all tags by name with the number of published posts carrying each
+func GetTags(c *gin.Context) {
+	rows, err := database.DB.Query(`SELECT ` + tagColumns + `, COUNT(p.id)
+		FROM tags t
+		LEFT JOIN post_tags pt ON pt.tag_id = t.id
+		LEFT JOIN (posts p JOIN categories c ON p.category_id = c.id)
+			ON p.id = pt.post_id AND ` + strings.Join(publicPostConditions, " AND ") + `
+		GROUP BY t.id
+		ORDER BY t.name ASC, t.id ASC`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		var count int
+		if err := scanTag(rows, &tag, &count); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan tag"})
+			return
+		}
+		tag.PostCount = &count
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+func CreateTag(c *gin.Context) {
+	var req models.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+
+	tagSlug, err := uniqueSlug("tags", req.Slug, req.Name, "tag", 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slug uniqueness"})
+		return
+	}
+
+	var tag models.Tag
+	row := database.DB.QueryRow(`INSERT INTO tags AS t (name, slug, description) VALUES ($1, $2, $3)
+		RETURNING `+tagColumns, req.Name, tagSlug, req.Description)
+	if err := scanTag(row, &tag); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Slug already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// UpdateTag renames a tag; an empty slug keeps the current one
+func UpdateTag(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	var req models.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+
+	tagSlug := ""
+	if req.Slug != "" {
+		if tagSlug, err = uniqueSlug("tags", req.Slug, req.Name, "tag", uint(id)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slug uniqueness"})
+			return
+		}
+	}
+
+	var tag models.Tag
+	row := database.DB.QueryRow(`UPDATE tags AS t SET name = $2, slug = COALESCE(NULLIF($3, ''), slug), description = $4,
+		updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING `+tagColumns, id, req.Name, tagSlug, req.Description)
+	if err := scanTag(row, &tag); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Slug already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag removes a tag from every post and deletes it
+func DeleteTag(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM tags WHERE id = $1", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag"})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
+
+// loadPostTags fills in the tags of posts with one query
+func loadPostTags(posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(posts))
+	index := make(map[uint]int, len(posts))
+	for i := range posts {
+		posts[i].Tags = []models.Tag{}
+		ids[i] = int64(posts[i].ID)
+		index[posts[i].ID] = i
+	}
+
+	rows, err := database.DB.Query(`SELECT pt.post_id, `+tagColumns+`
+		FROM post_tags pt JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.post_id = ANY($1::INTEGER[])
+		ORDER BY t.name ASC, t.id ASC`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID uint
+		var tag models.Tag
+		if err := rows.Scan(&postID, &tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return err
+		}
+		if i, ok := index[postID]; ok {
+			posts[i].Tags = append(posts[i].Tags, tag)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"house-design-backend/database"
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// Deleting a post or category moves it to the trash by setting deleted_at. Trashed rows are
+// hidden from every listing, can be restored, and are purged by database.PurgeTrash once
+// they are older than database.TrashRetention.
+
+// trashPost moves a post to the trash, reporting false when there is no such live post
+func trashPost(exec database.Execer, postID interface{}) (bool, error) {
+	result, err := exec.Exec("UPDATE posts SET deleted_at = NOW(), version = version + 1 WHERE id = $1 AND deleted_at IS NULL", postID)
+	if err != nil {
+		return false, err
+	}
+	trashed, _ := result.RowsAffected()
+	return trashed > 0, nil
+}
+
+// trashCategory moves a category, its live subcategories and the live posts inside them to
+// the trash and returns how many of each it moved. Everything gets the same deleted_at,
+// the transaction's time, which is how RestoreTrashedCategory finds what to bring back.
+func trashCategory(tx *sql.Tx, categoryID interface{}) (int, int64, error) {
+	rows, err := tx.Query(`WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL
+			UNION ALL
+			SELECT child.id FROM categories child JOIN tree ON child.parent_id = tree.id
+			WHERE child.deleted_at IS NULL
+		)
+		UPDATE categories SET deleted_at = NOW(), version = version + 1
+		WHERE id IN (SELECT id FROM tree)
+		RETURNING id`, categoryID)
+	if err != nil {
+		return 0, 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return 0, 0, err
+	}
+
+	result, err := tx.Exec(`UPDATE posts SET deleted_at = NOW(), version = version + 1
+		WHERE category_id = ANY($1::INTEGER[]) AND deleted_at IS NULL`, pq.Array(ids))
+	if err != nil {
+		return 0, 0, err
+	}
+	posts, _ := result.RowsAffected()
+	return len(ids), posts, nil
+}
+
+// GetTrash lists the trashed posts and categories, most recently deleted first
+func GetTrash(c *gin.Context) {
+	retention := database.TrashRetention()
+	response := models.TrashResponse{Posts: []models.TrashedPost{}, Categories: []models.TrashedCategory{}}
+
+	rows, err := database.DB.Query(`SELECT p.id, p.title, p.slug, p.category_id, c.name, p.deleted_at
+		FROM posts p JOIN categories c ON c.id = p.category_id
+		WHERE p.deleted_at IS NOT NULL
+		ORDER BY p.deleted_at DESC, p.id DESC`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var post models.TrashedPost
+		if err := rows.Scan(&post.ID, &post.Title, &post.Slug, &post.CategoryID, &post.CategoryName, &post.DeletedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan trashed post"})
+			return
+		}
+		post.PurgeAt = post.DeletedAt.Add(retention)
+		response.Posts = append(response.Posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	categoryRows, err := database.DB.Query(`SELECT c.id, c.name, c.slug, c.parent_id, c.deleted_at,
+			(SELECT COUNT(*) FROM posts p WHERE p.category_id = c.id AND p.deleted_at = c.deleted_at)
+		FROM categories c
+		WHERE c.deleted_at IS NOT NULL
+		ORDER BY c.deleted_at DESC, c.level ASC, c.id ASC`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+	defer categoryRows.Close()
+	for categoryRows.Next() {
+		var category models.TrashedCategory
+		if err := categoryRows.Scan(&category.ID, &category.Name, &category.Slug, &category.ParentID,
+			&category.DeletedAt, &category.PostCount); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan trashed category"})
+			return
+		}
+		category.PurgeAt = category.DeletedAt.Add(retention)
+		response.Categories = append(response.Categories, category)
+	}
+	if err := categoryRows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreTrashedPost takes a post out of the trash. A post whose category is in the trash
+// cannot be restored on its own: the category has to be restored first.
+func RestoreTrashedPost(c *gin.Context) {
+	id := c.Param("id")
+
+	var categoryID uint
+	var categoryTrashed bool
+	err := database.DB.QueryRow(`SELECT p.category_id, c.deleted_at IS NOT NULL
+		FROM posts p JOIN categories c ON c.id = p.category_id
+		WHERE p.id = $1 AND p.deleted_at IS NOT NULL`, id).Scan(&categoryID, &categoryTrashed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found in trash"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch post"})
+		return
+	}
+	if categoryTrashed {
+		c.JSON(http.StatusConflict, gin.H{"error": "The post's category is in the trash, restore it first", "category_id": categoryID})
+		return
+	}
+
+	if _, err := database.DB.Exec(`UPDATE posts SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL`, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore post"})
+		return
+	}
+
+	getPost(c, []string{"p.id = $1", livePostCondition}, id)
+}
+
+// PurgeTrashedPost deletes a trashed post for good, with its revisions and tags
+func PurgeTrashedPost(c *gin.Context) {
+	result, err := database.DB.Exec("DELETE FROM posts WHERE id = $1 AND deleted_at IS NOT NULL", c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge post"})
+		return
+	}
+	if purged, _ := result.RowsAffected(); purged == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found in trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post deleted permanently"})
+}
+
+// RestoreTrashedCategory takes a category out of the trash together with the subcategories
+// and posts that were trashed with it. Items deleted separately stay in the trash. A
+// category whose parent is in the trash cannot be restored before the parent.
+func RestoreTrashedCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	var deletedAt time.Time
+	var parentTrashed bool
+	err = tx.QueryRow(`SELECT c.deleted_at, COALESCE(parent.deleted_at IS NOT NULL, FALSE)
+		FROM categories c LEFT JOIN categories parent ON parent.id = c.parent_id
+		WHERE c.id = $1 AND c.deleted_at IS NOT NULL
+		FOR UPDATE OF c`, id).Scan(&deletedAt, &parentTrashed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found in trash"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category"})
+		return
+	}
+	if parentTrashed {
+		c.JSON(http.StatusConflict, gin.H{"error": "The parent category is in the trash, restore it first"})
+		return
+	}
+
+	var restoredCategories int
+	var restoredPosts int64
+	err = tx.QueryRow(`WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION ALL
+			SELECT child.id FROM categories child JOIN tree ON child.parent_id = tree.id
+			WHERE child.deleted_at = $2
+		), restored AS (
+			UPDATE categories SET deleted_at = NULL, version = version + 1
+			WHERE id IN (SELECT id FROM tree) AND deleted_at = $2
+			RETURNING id
+		)
+		SELECT COUNT(*) FROM restored`, id, deletedAt).Scan(&restoredCategories)
+	if err == nil {
+		var result sql.Result
+		result, err = tx.Exec(`UPDATE posts p SET deleted_at = NULL, version = version + 1
+			FROM categories c
+			WHERE c.id = p.category_id AND c.deleted_at IS NULL AND p.deleted_at = $1`, deletedAt)
+		if err == nil {
+			restoredPosts, _ = result.RowsAffected()
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore category"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "Category restored",
+		"restored_categories": restoredCategories,
+		"restored_posts":      restoredPosts,
+	})
+}
+
+// PurgeTrashedCategory deletes a trashed category for good, with the subcategories and
+// posts trashed under it. It refuses while anything live still sits below the category.
+func PurgeTrashedCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	var trashed, hasLiveContent bool
+	err := database.DB.QueryRow(`WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION ALL
+			SELECT child.id FROM categories child JOIN tree ON child.parent_id = tree.id
+		)
+		SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1 AND deleted_at IS NOT NULL),
+			EXISTS(SELECT 1 FROM categories WHERE id IN (SELECT id FROM tree) AND deleted_at IS NULL)
+			OR EXISTS(SELECT 1 FROM posts WHERE category_id IN (SELECT id FROM tree) AND deleted_at IS NULL)`,
+		id).Scan(&trashed, &hasLiveContent)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category"})
+		return
+	}
+	if !trashed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found in trash"})
+		return
+	}
+	if hasLiveContent {
+		c.JSON(http.StatusConflict, gin.H{"error": "The category still contains posts or subcategories outside the trash"})
+		return
+	}
+
+	// Trashed subcategories and posts go with it through ON DELETE CASCADE
+	if _, err := database.DB.Exec("DELETE FROM categories WHERE id = $1 AND deleted_at IS NOT NULL", id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted permanently"})
+}
@@ -0,0 +1,436 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"house-design-backend/database"
+	"house-design-backend/middleware"
+	"house-design-backend/models"
+	"house-design-backend/totp"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const recoveryCodeCount = 10
+
+// generateRecoveryCodes returns single-use codes formatted as xxxxx-xxxxx
+func generateRecoveryCodes() ([]string, error) {
+	// 32 symbols without look-alikes (no i, l, o, 1) so every random byte maps evenly
+	const alphabet = "abcdefghjkmnpqrstuvwxyz023456789"
+	codes := make([]string, recoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		var code strings.Builder
+		for j, b := range buf {
+			if j == 5 {
+				code.WriteByte('-')
+			}
+			code.WriteByte(alphabet[int(b)%len(alphabet)])
+		}
+		codes[i] = code.String()
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode accepts codes typed in upper case or without the dash
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	if len(code) == 10 && !strings.Contains(code, "-") {
+		code = code[:5] + "-" + code[5:]
+	}
+	return code
+}
+
+// checkTOTP validates code against the admin's secret and consumes its time step so the
+// same code cannot be replayed
+func checkTOTP(adminID uint, secret, code string) (bool, error) {
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	result, err := database.DB.Exec("UPDATE admin SET totp_last_counter = $2 WHERE id = $1 AND COALESCE(totp_last_counter, 0) < $2",
+		adminID, step)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// VerifyTwoFactor completes a login started with a password by checking the TOTP or recovery code
+func VerifyTwoFactor(c *gin.Context) {
+	var req models.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := middleware.ParseTwoFactorChallenge(req.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge, please log in again"})
+		return
+	}
+
+	if !checkLoginLockout(c, claims.Username) {
+		return
+	}
+
+	var admin models.Admin
+	var secret sql.NullString
+	err = database.DB.QueryRow(`SELECT id, username, COALESCE(email, ''), COALESCE(display_name, ''), role,
+		COALESCE(is_active, TRUE), COALESCE(totp_enabled, FALSE), COALESCE(must_change_password, FALSE), totp_secret
+		FROM admin WHERE id = $1`, claims.UserID).Scan(&admin.ID, &admin.Username, &admin.Email, &admin.DisplayName,
+		&admin.Role, &admin.IsActive, &admin.TwoFactorEnabled, &admin.MustChangePassword, &secret)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge, please log in again"})
+		return
+	}
+	if !admin.IsActive {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
+	if !admin.TwoFactorEnabled || !secret.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled, please log in again"})
+		return
+	}
+
+	reason := "2fa_totp"
+	valid, err := checkTOTP(admin.ID, secret.String, req.Code)
+	if err == nil && !valid {
+		reason = "2fa_recovery_code"
+		valid, err = database.UseRecoveryCode(admin.ID, normalizeRecoveryCode(req.Code))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !valid {
+		registerLoginFailure(c, admin.Username)
+		recordLoginAttempt(c, admin.Username, false, "invalid_2fa_code")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	clearLoginFailures(admin.Username)
+	recordLoginAttempt(c, admin.Username, true, reason)
+
+	response, err := issueTokens(c, admin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTwoFactorStatus reports whether the current user has 2FA and how many recovery codes are left
+func GetTwoFactorStatus(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var enabled bool
+	if err := database.DB.QueryRow("SELECT COALESCE(totp_enabled, FALSE) FROM admin WHERE id = $1", userID).Scan(&enabled); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	remaining, err := database.CountUnusedRecoveryCodes(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count recovery codes"})
+		return
+	}
+
+	settings, err := database.GetSecuritySettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch security settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":                  enabled,
+		"required":                 settings.RequireTwoFactor,
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// SetupTwoFactor generates a new TOTP secret for the current user. 2FA only becomes active
+// once EnableTwoFactor confirms a code from the authenticator app.
+func SetupTwoFactor(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var username string
+	var enabled bool
+	err := database.DB.QueryRow("SELECT username, COALESCE(totp_enabled, FALSE) FROM admin WHERE id = $1", userID).Scan(&username, &enabled)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+
+	if _, err := database.DB.Exec("UPDATE admin SET totp_secret = $2, totp_last_counter = 0 WHERE id = $1", userID, secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store secret"})
+		return
+	}
+
+	issuer := "MMA Architectural Design"
+	if settings, err := database.GetGlobalSEOSettings(); err == nil && settings != nil && settings.SiteName != "" {
+		issuer = settings.SiteName
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(secret, issuer, username),
+	})
+}
+
+// EnableTwoFactor confirms the pending secret with a code and returns fresh recovery codes
+func EnableTwoFactor(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	var secret sql.NullString
+	var enabled bool
+	err := database.DB.QueryRow("SELECT totp_secret, COALESCE(totp_enabled, FALSE) FROM admin WHERE id = $1", userID).Scan(&secret, &enabled)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if !secret.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Call /api/auth/2fa/setup first"})
+		return
+	}
+
+	valid, err := checkTOTP(userID, secret.String, req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE admin SET totp_enabled = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = $1", userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	if err := database.ReplaceRecoveryCodes(tx, userID, codes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store recovery codes"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes after checking a TOTP code
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	var secret sql.NullString
+	var enabled bool
+	err := database.DB.QueryRow("SELECT totp_secret, COALESCE(totp_enabled, FALSE) FROM admin WHERE id = $1", userID).Scan(&secret, &enabled)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !enabled || !secret.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	valid, err := checkTOTP(userID, secret.String, req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	if err := database.ReplaceRecoveryCodes(tx, userID, codes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store recovery codes"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DisableTwoFactor turns off 2FA for the current user after re-checking their password
+func DisableTwoFactor(c *gin.Context) {
+	var req models.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := database.GetSecuritySettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch security settings"})
+		return
+	}
+	if settings.RequireTwoFactor {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is mandatory on this site"})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	var passwordHash string
+	if err := database.DB.QueryRow("SELECT password FROM admin WHERE id = $1", userID).Scan(&passwordHash); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)) != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := database.DisableTwoFactor(tx, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// ResetUserTwoFactor lets an owner remove 2FA from an account that lost its authenticator.
+// All sessions of that account are revoked.
+func ResetUserTwoFactor(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	found, err := database.DisableTwoFactor(tx, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset two-factor authentication"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if _, err := database.RevokeAllSessions(tx, uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset"})
+}
+
+// GetSecuritySettings returns the site-wide authentication policy
+func GetSecuritySettings(c *gin.Context) {
+	settings, err := database.GetSecuritySettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch security settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSecuritySettings changes the site-wide authentication policy
+func UpdateSecuritySettings(c *gin.Context) {
+	var settings models.SecuritySettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.UpdateSecuritySettings(&settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update security settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"house-design-backend/database"
+	"house-design-backend/middleware"
+	"house-design-backend/models"
+	"house-design-backend/password"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const adminUserColumns = `id, username, COALESCE(email, '') as email, COALESCE(display_name, '') as display_name,
+	COALESCE(avatar_url, '') as avatar_url, COALESCE(bio, '') as bio, role, COALESCE(is_active, TRUE) as is_active, COALESCE(totp_enabled, FALSE) as totp_enabled,
+	COALESCE(must_change_password, FALSE) as must_change_password, created_at, updated_at`
+
+func scanAdminUser(row interface{ Scan(...interface{}) error }, user *models.Admin) error {
+	return row.Scan(&user.ID, &user.Username, &user.Email, &user.DisplayName, &user.AvatarURL, &user.Bio,
+		&user.Role, &user.IsActive, &user.TwoFactorEnabled, &user.MustChangePassword, &user.CreatedAt, &user.UpdatedAt)
+}
+
+// GetCurrentUser returns the authenticated user together with their permissions
+func GetCurrentUser(c *gin.Context) {
+	var user models.Admin
+	row := database.DB.QueryRow("SELECT "+adminUserColumns+" FROM admin WHERE id = $1", c.GetUint("user_id"))
+	if err := scanAdminUser(row, &user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":        user,
+		"permissions": middleware.PermissionsForRole(user.Role),
+	})
+}
+
+// UpdateProfile changes the byline (display name, avatar, bio) of the logged-in user
+func UpdateProfile(c *gin.Context) {
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.Admin
+	row := database.DB.QueryRow(`UPDATE admin SET display_name = COALESCE($2, display_name), avatar_url = COALESCE($3, avatar_url),
+		bio = COALESCE($4, bio), updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING `+adminUserColumns,
+		c.GetUint("user_id"), req.DisplayName, req.AvatarURL, req.Bio)
+	if err := scanAdminUser(row, &user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// Users handlers
+func GetUsers(c *gin.Context) {
+	rows, err := database.DB.Query("SELECT " + adminUserColumns + " FROM admin ORDER BY id ASC")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	defer rows.Close()
+
+	users := []models.Admin{}
+	for rows.Next() {
+		var user models.Admin
+		if err := scanAdminUser(rows, &user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan user"})
+			return
+		}
+		users = append(users, user)
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func GetUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var user models.Admin
+	row := database.DB.QueryRow("SELECT "+adminUserColumns+" FROM admin WHERE id = $1", id)
+	if err := scanAdminUser(row, &user); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func CreateUser(c *gin.Context) {
+	var req models.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required"})
+		return
+	}
+	if !models.IsValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+	if err := password.Validate(req.Password, req.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	var user models.Admin
+	row := database.DB.QueryRow(`INSERT INTO admin (username, password, email, display_name, avatar_url, bio, role, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, TRUE) RETURNING `+adminUserColumns,
+		req.Username, string(hashedPassword), req.Email, req.DisplayName, req.AvatarURL, req.Bio, req.Role)
+	if err := scanAdminUser(row, &user); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+func UpdateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	// A role or status change locks the owners before the user, in the same order as
+	// DeleteUser, so that concurrent changes neither deadlock nor remove the last owner
+	changesOwnership := req.Role != nil || req.IsActive != nil
+	otherOwner := false
+	if changesOwnership {
+		if otherOwner, err = hasOtherActiveOwner(tx, uint(id)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check owners"})
+			return
+		}
+	}
+
+	// Read under the row lock so that the columns written back below are not stale
+	var existing models.Admin
+	row := tx.QueryRow("SELECT "+adminUserColumns+" FROM admin WHERE id = $1 FOR UPDATE", id)
+	if err := scanAdminUser(row, &existing); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+
+	if req.Email != nil {
+		existing.Email = *req.Email
+	}
+	if req.DisplayName != nil {
+		existing.DisplayName = *req.DisplayName
+	}
+	if req.AvatarURL != nil {
+		existing.AvatarURL = *req.AvatarURL
+	}
+	if req.Bio != nil {
+		existing.Bio = *req.Bio
+	}
+	if req.Role != nil {
+		if !models.IsValidRole(*req.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+			return
+		}
+		existing.Role = *req.Role
+	}
+	if req.IsActive != nil {
+		existing.IsActive = *req.IsActive
+	}
+
+	// Never leave the site without an active owner
+	if changesOwnership && (existing.Role != models.RoleOwner || !existing.IsActive) && !otherOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one active owner is required"})
+		return
+	}
+
+	if req.Password != nil {
+		if err := password.Validate(*req.Password, existing.Username); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+			return
+		}
+		if err := database.SetAdminPassword(tx, uint(id), string(hashedPassword)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+			return
+		}
+	}
+
+	// Changing the password or disabling the account ends every existing session
+	if req.Password != nil || !existing.IsActive {
+		if _, err := database.RevokeAllSessions(tx, uint(id)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+	}
+
+	row = tx.QueryRow(`UPDATE admin SET email = $2, display_name = $3, avatar_url = $4, bio = $5, role = $6, is_active = $7,
+		updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING `+adminUserColumns,
+		id, existing.Email, existing.DisplayName, existing.AvatarURL, existing.Bio, existing.Role, existing.IsActive)
+	var user models.Admin
+	if err := scanAdminUser(row, &user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func DeleteUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if uint(id) == c.GetUint("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot delete your own account"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	if ok, err := hasOtherActiveOwner(tx, uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check owners"})
+		return
+	} else if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one active owner is required"})
+		return
+	}
+
+	result, err := tx.Exec("DELETE FROM admin WHERE id = $1", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+// hasOtherActiveOwner reports whether an active owner other than excludeID exists. It locks
+// the rows of every active owner until tx ends, so two requests demoting or deleting the
+// last two owners cannot both see the other one and succeed.
+func hasOtherActiveOwner(tx *sql.Tx, excludeID uint) (bool, error) {
+	rows, err := tx.Query("SELECT id FROM admin WHERE role = $1 AND COALESCE(is_active, TRUE) ORDER BY id FOR UPDATE",
+		models.RoleOwner)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	found := false
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return false, err
+		}
+		if id != excludeID {
+			found = true
+		}
+	}
+	return found, rows.Err()
+}
@@ -0,0 +1,130 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// ErrNotConfigured is returned by Send when no mailer is configured
+var ErrNotConfigured = errors.New("mailer: no mailer is configured")
+
+// Default is the mailer used by the handlers, set up by Init. It is nil when email is off.
+var Default Mailer
+
+// Init selects the mailer from the environment: SMTP when SMTP_HOST is set, or the log when
+// MAILER=log. The log receives whole messages, password reset links included, so it has to
+// be asked for explicitly. Otherwise email stays off and features that need it are refused.
+func Init() {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		if os.Getenv("MAILER") == "log" {
+			log.Println("Warning: MAILER=log, outgoing email including password reset links is written to the log")
+			Default = LogMailer{}
+			return
+		}
+		log.Println("Warning: SMTP_HOST is not set, email is off and password reset is unavailable")
+		Default = nil
+		return
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "no-reply@" + host
+	}
+
+	Default = &SMTPMailer{
+		Addr:     net.JoinHostPort(host, port),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	}
+	log.Printf("Outgoing email via SMTP %s", net.JoinHostPort(host, port))
+}
+
+// Configured reports whether outgoing email is set up
+func Configured() bool {
+	return Default != nil
+}
+
+// Send delivers msg through the Default mailer
+func Send(msg Message) error {
+	if Default == nil {
+		return ErrNotConfigured
+	}
+	return Default.Send(msg)
+}
+
+// LogMailer writes messages to the log instead of sending them. Only for development, as
+// anyone reading the log can use the links in the messages.
+type LogMailer struct{}
+
+func (LogMailer) Send(msg Message) error {
+	log.Printf("Email to %s\nSubject: %s\n\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// SMTPMailer sends email through an SMTP server. STARTTLS is used when the server offers it;
+// authentication is only attempted when Username is set, so a local SMTP stand-in such as
+// MailHog or smtp4dev works without credentials.
+type SMTPMailer struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	for _, value := range []string{msg.To, msg.Subject, m.From} {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("mailer: header contains a line break")
+		}
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	return smtp.SendMail(m.Addr, auth, m.From, []string{msg.To}, m.build(msg))
+}
+
+func (m *SMTPMailer) build(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	// SMTP requires CRLF line endings
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,186 @@
+package mailer
+
+import (
+	"bufio"
+	"fmt"
+	"mime"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the stub server received from one client
+type smtpSession struct {
+	From string
+	To   []string
+	Data string
+}
+
+// startSMTPStub listens on a local port and records the first SMTP session it serves.
+// It offers neither STARTTLS nor AUTH, like MailHog.
+func startSMTPStub(t *testing.T) (string, <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		sessions <- serveSMTP(conn)
+	}()
+	return listener.Addr().String(), sessions
+}
+
+// serveSMTP answers one client until it quits. The message data is kept as sent, with its
+// line endings, and without the terminating dot line.
+func serveSMTP(conn net.Conn) smtpSession {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	var session smtpSession
+	reply("220 localhost ESMTP stub")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return session
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			session.From = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			session.To = append(session.To, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return session
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			session.Data = data.String()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return session
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	addr, sessions := startSMTPStub(t)
+	m := &SMTPMailer{Addr: addr, From: "no-reply@example.com"}
+	msg := Message{
+		To:      "reader@example.com",
+		Subject: "Đặt lại mật khẩu / Password reset",
+		Body:    "Xin chào,\nline two\r\nline three",
+	}
+	if err := m.Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var session smtpSession
+	select {
+	case session = <-sessions:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stub received no session")
+	}
+
+	if session.From != "no-reply@example.com" {
+		t.Errorf("MAIL FROM = %q, want no-reply@example.com", session.From)
+	}
+	if len(session.To) != 1 || session.To[0] != "reader@example.com" {
+		t.Errorf("RCPT TO = %q, want [reader@example.com]", session.To)
+	}
+
+	if bare := strings.Count(session.Data, "\n") - strings.Count(session.Data, "\r\n"); bare != 0 {
+		t.Errorf("data has %d bare LF line endings:\n%q", bare, session.Data)
+	}
+	header, body, found := strings.Cut(session.Data, "\r\n\r\n")
+	if !found {
+		t.Fatalf("no blank line between header and body:\n%q", session.Data)
+	}
+	if want := "Xin chào,\r\nline two\r\nline three\r\n"; body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+
+	fields := map[string]string{}
+	for _, line := range strings.Split(header, "\r\n") {
+		name, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			t.Fatalf("malformed header line %q", line)
+		}
+		fields[name] = value
+	}
+	for name, want := range map[string]string{
+		"From":                      "no-reply@example.com",
+		"To":                        "reader@example.com",
+		"MIME-Version":              "1.0",
+		"Content-Type":              "text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding": "8bit",
+	} {
+		if fields[name] != want {
+			t.Errorf("%s header = %q, want %q", name, fields[name], want)
+		}
+	}
+	if _, err := time.Parse(time.RFC1123Z, fields["Date"]); err != nil {
+		t.Errorf("Date header %q: %v", fields["Date"], err)
+	}
+
+	subject := fields["Subject"]
+	if !strings.HasPrefix(subject, "=?UTF-8?q?") {
+		t.Errorf("Subject header %q is not Q-encoded", subject)
+	}
+	for _, r := range subject {
+		if r > 127 {
+			t.Errorf("Subject header %q contains non-ASCII characters", subject)
+			break
+		}
+	}
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err != nil || decoded != msg.Subject {
+		t.Errorf("Subject decodes to %q (%v), want %q", decoded, err, msg.Subject)
+	}
+}
+
+func TestSMTPMailerSendRejectsHeaderInjection(t *testing.T) {
+	for name, msg := range map[string]Message{
+		"to":         {To: "reader@example.com\r\nBcc: victim@example.com", Subject: "Hello", Body: "Hi"},
+		"to LF":      {To: "reader@example.com\nBcc: victim@example.com", Subject: "Hello", Body: "Hi"},
+		"subject":    {To: "reader@example.com", Subject: "Hello\r\nBcc: victim@example.com", Body: "Hi"},
+		"subject CR": {To: "reader@example.com", Subject: "Hello\rBcc: victim@example.com", Body: "Hi"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			addr, sessions := startSMTPStub(t)
+			m := &SMTPMailer{Addr: addr, From: "no-reply@example.com"}
+			if err := m.Send(msg); err == nil || !strings.Contains(err.Error(), "line break") {
+				t.Fatalf("Send error = %v, want a line break error", err)
+			}
+			select {
+			case session := <-sessions:
+				t.Fatalf("the stub received a session: %+v", session)
+			default:
+			}
+		})
+	}
+}
@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"house-design-backend/config"
 	"house-design-backend/database"
 	"house-design-backend/handlers"
+	"house-design-backend/mailer"
 	"house-design-backend/middleware"
+	"house-design-backend/scheduler"
+	"house-design-backend/viewcounter"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -21,10 +30,30 @@ func main() {
 		log.Println("Warning: Failed to load .env file:", err)
 	}
 
+	// Load JWT signing and verification keys
+	if err := middleware.InitKeys(); err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
+	}
+
+	// Outgoing email (password reset links)
+	mailer.Init()
+
 	// Initialize database
 	database.InitDatabase()
 	defer database.DB.Close()
 
+	// Maintenance commands run against the database and exit instead of starting the server
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1:])
+		return
+	}
+
+	// Background jobs, safe to run on every instance
+	scheduler.Every("post schedule", config.GetDuration("POST_SCHEDULE_INTERVAL", time.Minute), database.ApplyPostSchedule)
+	scheduler.Every("post views", config.GetDuration("VIEW_FLUSH_INTERVAL", 30*time.Second), viewcounter.Flush)
+	scheduler.Every("trash purge", config.GetDuration("TRASH_PURGE_INTERVAL", time.Hour), database.PurgeTrash)
+	scheduler.Every("login audit purge", config.GetDuration("LOGIN_ATTEMPT_PURGE_INTERVAL", time.Hour), database.PurgeLoginAttempts)
+
 	// Initialize Gin router
 	r := gin.Default()
 
@@ -43,9 +72,9 @@ func main() {
 
 			return false
 		},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key", "If-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag"},
 		AllowCredentials: true,
 	}))
 
@@ -56,13 +85,24 @@ func main() {
 		auth := api.Group("/auth")
 		{
 			auth.POST("/login", handlers.Login)
+			auth.POST("/2fa/verify", handlers.VerifyTwoFactor)
+			auth.POST("/refresh", handlers.RefreshToken)
+			auth.POST("/forgot-password", handlers.ForgotPassword)
+			auth.POST("/reset-password", handlers.ResetPassword)
 			auth.POST("/logout", handlers.Logout)
+			auth.GET("/jwks.json", handlers.GetJWKS)
 		}
 
 		// Public routes
 		api.GET("/categories", handlers.GetCategories)
 		api.GET("/posts", handlers.GetPosts)
 		api.GET("/posts/:id", handlers.GetPost)
+		api.POST("/posts/:id/view", handlers.RecordPostView)
+		api.GET("/posts/slug/:slug", handlers.GetPostBySlug)
+		api.GET("/categories/:slug/posts/:post_slug", handlers.GetCategoryPostBySlug)
+		api.GET("/tags", handlers.GetTags)
+		api.GET("/authors/:id", handlers.GetAuthor)
+		api.GET("/search", handlers.SearchPosts)
 		api.GET("/homepage/media", handlers.GetHomepageImages)
 		api.GET("/home-content", handlers.GetHomeContent)
 		api.GET("/footer-content", handlers.GetFooterContent)
@@ -72,36 +112,107 @@ func main() {
 		protected := api.Group("/")
 		protected.Use(middleware.AuthMiddleware())
 		{
+			// Account routes act on the logged-in user and are not available to API keys
+			account := protected.Group("/auth", middleware.RequireUserSession())
+			{
+				// Current user
+				account.GET("/me", handlers.GetCurrentUser)
+				account.PUT("/profile", handlers.UpdateProfile)
+
+				// Session management
+				account.GET("/sessions", handlers.GetSessions)
+				account.DELETE("/sessions/:id", handlers.RevokeUserSession)
+				account.POST("/logout-all", handlers.LogoutAll)
+				account.POST("/password", handlers.ChangePassword)
+
+				// Two-factor authentication for the current user
+				account.GET("/2fa", handlers.GetTwoFactorStatus)
+				account.POST("/2fa/setup", handlers.SetupTwoFactor)
+				account.POST("/2fa/enable", handlers.EnableTwoFactor)
+				account.POST("/2fa/disable", handlers.DisableTwoFactor)
+				account.POST("/2fa/recovery-codes", handlers.RegenerateRecoveryCodes)
+			}
+
+			// User management
+			protected.GET("/users", middleware.RequirePermission(middleware.PermManageUsers), handlers.GetUsers)
+			protected.GET("/users/:id", middleware.RequirePermission(middleware.PermManageUsers), handlers.GetUser)
+			protected.POST("/users", middleware.RequirePermission(middleware.PermManageUsers), handlers.CreateUser)
+			protected.PUT("/users/:id", middleware.RequirePermission(middleware.PermManageUsers), handlers.UpdateUser)
+			protected.DELETE("/users/:id", middleware.RequirePermission(middleware.PermManageUsers), handlers.DeleteUser)
+			protected.DELETE("/users/:id/2fa", middleware.RequirePermission(middleware.PermManageUsers), handlers.ResetUserTwoFactor)
+			protected.GET("/security-settings", middleware.RequirePermission(middleware.PermManageUsers), handlers.GetSecuritySettings)
+			protected.PUT("/security-settings", middleware.RequirePermission(middleware.PermManageUsers), handlers.UpdateSecuritySettings)
+
+			// API keys for machine clients
+			protected.GET("/api-keys", middleware.RequirePermission(middleware.PermManageAPIKeys), handlers.GetAPIKeys)
+			protected.POST("/api-keys", middleware.RequirePermission(middleware.PermManageAPIKeys), handlers.CreateAPIKey)
+			protected.DELETE("/api-keys/:id", middleware.RequirePermission(middleware.PermManageAPIKeys), handlers.RevokeAPIKey)
+
+			// Login security
+			protected.GET("/auth/login-attempts", middleware.RequirePermission(middleware.PermManageUsers), handlers.GetLoginAttempts)
+			protected.GET("/auth/lockouts", middleware.RequirePermission(middleware.PermManageUsers), handlers.GetLoginLockouts)
+			protected.POST("/auth/unlock", middleware.RequirePermission(middleware.PermManageUsers), handlers.UnlockLogin)
+
 			// Categories management
-			protected.POST("/categories", handlers.CreateCategory)
-			protected.PUT("/categories/:id", handlers.UpdateCategory)
-			protected.PUT("/categories/update-order", handlers.UpdateCategoryOrder)
-			protected.DELETE("/categories/:id", handlers.DeleteCategory)
+			protected.POST("/categories", middleware.RequirePermission(middleware.PermManageCategories), handlers.CreateCategory)
+			protected.PUT("/categories/:id", middleware.RequirePermission(middleware.PermManageCategories), handlers.UpdateCategory)
+			protected.PUT("/categories/update-order", middleware.RequirePermission(middleware.PermManageCategories), handlers.UpdateCategoryOrder)
+			protected.DELETE("/categories/:id", middleware.RequirePermission(middleware.PermManageCategories), handlers.DeleteCategory)
+
+			// Tags management
+			protected.POST("/tags", middleware.RequirePermission(middleware.PermManageCategories), handlers.CreateTag)
+			protected.PUT("/tags/:id", middleware.RequirePermission(middleware.PermManageCategories), handlers.UpdateTag)
+			protected.DELETE("/tags/:id", middleware.RequirePermission(middleware.PermManageCategories), handlers.DeleteTag)
+
+			// Admin listings include drafts and inactive categories
+			protected.GET("/admin/categories", middleware.RequirePermission(middleware.PermReadAdmin), handlers.GetAdminCategories)
+			protected.GET("/admin/categories/:id", middleware.RequirePermission(middleware.PermReadAdmin), handlers.GetAdminCategory)
+			protected.GET("/admin/categories/:id/deletion-preview", middleware.RequirePermission(middleware.PermReadAdmin), handlers.GetCategoryDeletionPreview)
+			protected.GET("/admin/posts", middleware.RequirePermission(middleware.PermReadPosts), handlers.GetAdminPosts)
+			protected.GET("/admin/posts/scheduled", middleware.RequirePermission(middleware.PermReadPosts), handlers.GetScheduledPosts)
+			protected.GET("/admin/posts/:id", middleware.RequirePermission(middleware.PermReadPosts), handlers.GetAdminPost)
+			protected.GET("/admin/posts/:id/revisions", middleware.RequirePermission(middleware.PermReadPosts), handlers.GetPostRevisions)
+			protected.GET("/admin/posts/:id/revisions/diff", middleware.RequirePermission(middleware.PermReadPosts), handlers.GetPostRevisionDiff)
+			protected.GET("/admin/posts/:id/revisions/:revision", middleware.RequirePermission(middleware.PermReadPosts), handlers.GetPostRevision)
 
 			// Posts management
-			protected.POST("/posts", handlers.CreatePost)
-			protected.PUT("/posts/:id", handlers.UpdatePost)
-			protected.DELETE("/posts/:id", handlers.DeletePost)
+			protected.POST("/posts", middleware.RequirePermission(middleware.PermWritePosts), handlers.CreatePost)
+			protected.POST("/posts/bulk", middleware.RequirePermission(middleware.PermWritePosts), handlers.BulkPosts)
+			protected.POST("/posts/import", middleware.RequirePermission(middleware.PermWritePosts), handlers.ImportMarkdownPosts)
+			protected.PUT("/posts/:id", middleware.RequirePermission(middleware.PermWritePosts), handlers.UpdatePost)
+			protected.PATCH("/posts/:id", middleware.RequirePermission(middleware.PermWritePosts), handlers.PatchPost)
+			protected.POST("/posts/:id/revisions/:revision/restore", middleware.RequirePermission(middleware.PermWritePosts), handlers.RestorePostRevision)
+			protected.DELETE("/posts/:id", middleware.RequirePermission(middleware.PermDeletePosts), handlers.DeletePost)
+
+			// Trash: deleted posts and categories until they are purged
+			protected.GET("/admin/trash", middleware.RequirePermission(middleware.PermReadAdmin), handlers.GetTrash)
+			protected.POST("/trash/posts/:id/restore", middleware.RequirePermission(middleware.PermDeletePosts), handlers.RestoreTrashedPost)
+			protected.DELETE("/trash/posts/:id", middleware.RequirePermission(middleware.PermDeletePosts), handlers.PurgeTrashedPost)
+			protected.POST("/trash/categories/:id/restore", middleware.RequirePermission(middleware.PermManageCategories), handlers.RestoreTrashedCategory)
+			protected.DELETE("/trash/categories/:id", middleware.RequirePermission(middleware.PermManageCategories), handlers.PurgeTrashedCategory)
 
 			// Media uploads
-			protected.POST("/upload", handlers.UploadImage)
-			protected.POST("/upload-video", handlers.UploadVideo)
-			protected.POST("/upload-svg-icon", handlers.UploadSvgIcon)
+			protected.POST("/upload", middleware.RequirePermission(middleware.PermUploadMedia), handlers.UploadImage)
+			protected.POST("/upload-video", middleware.RequirePermission(middleware.PermUploadMedia), handlers.UploadVideo)
+			protected.POST("/upload-svg-icon", middleware.RequirePermission(middleware.PermUploadMedia), handlers.UploadSvgIcon)
 
 			// Homepage media management
-			protected.POST("/homepage/upload-image", handlers.UploadHomepageImage)
-			protected.POST("/homepage/upload-video", handlers.UploadHomepageVideo)
-			protected.DELETE("/homepage/:type/:filename", handlers.DeleteHomepageMedia)
-			protected.PUT("/homepage/:type/:filename", handlers.ReplaceHomepageMedia)
+			protected.POST("/homepage/upload-image", middleware.RequirePermission(middleware.PermManageSiteContent), handlers.UploadHomepageImage)
+			protected.POST("/homepage/upload-video", middleware.RequirePermission(middleware.PermManageSiteContent), handlers.UploadHomepageVideo)
+			protected.DELETE("/homepage/:type/:filename", middleware.RequirePermission(middleware.PermManageSiteContent), handlers.DeleteHomepageMedia)
+			protected.PUT("/homepage/:type/:filename", middleware.RequirePermission(middleware.PermManageSiteContent), handlers.ReplaceHomepageMedia)
 
 			// Home content management
-			protected.PUT("/home-content", handlers.UpdateHomeContent)
+			protected.PUT("/home-content", middleware.RequirePermission(middleware.PermManageSiteContent), handlers.UpdateHomeContent)
+			protected.PATCH("/home-content", middleware.RequirePermission(middleware.PermManageSiteContent), handlers.PatchHomeContent)
 
 			// Footer content management
-			protected.PUT("/footer-content", handlers.UpdateFooterContent)
+			protected.PUT("/footer-content", middleware.RequirePermission(middleware.PermManageSiteContent), handlers.UpdateFooterContent)
+			protected.PATCH("/footer-content", middleware.RequirePermission(middleware.PermManageSiteContent), handlers.PatchFooterContent)
 
 			// SEO settings management
-			protected.PUT("/seo-settings", handlers.UpdateGlobalSEOSettings)
+			protected.PUT("/seo-settings", middleware.RequirePermission(middleware.PermManageSEO), handlers.UpdateGlobalSEOSettings)
+			protected.PATCH("/seo-settings", middleware.RequirePermission(middleware.PermManageSEO), handlers.PatchGlobalSEOSettings)
 		}
 	}
 
@@ -124,8 +235,27 @@ func main() {
 	}
 
 	address := "0.0.0.0:" + port
-	log.Printf("Server starting on %s", address)
-	if err := r.Run(address); err != nil {
-		log.Fatal("Failed to start server:", err)
+	server := &http.Server{Addr: address, Handler: r}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		log.Printf("Server starting on %s", address)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// On SIGTERM or Ctrl+C, finish the requests in flight, then write the view counts still
+	// buffered in memory so that a deploy does not lose them
+	<-ctx.Done()
+	stop()
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.GetDuration("SHUTDOWN_TIMEOUT", 10*time.Second))
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to finish requests before shutting down: %v", err)
+	}
+	if err := viewcounter.Flush(); err != nil {
+		log.Printf("Failed to write buffered post views: %v", err)
 	}
 }
@@ -0,0 +1,330 @@
+package mdimport
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"house-design-backend/database"
+	"house-design-backend/sanitize"
+	"house-design-backend/slug"
+
+	"github.com/lib/pq"
+)
+
+// UploadsDir is where imported images are stored, served under /data/uploads/images
+const UploadsDir = "./data/uploads/images"
+
+const (
+	// MaxImageSize matches the limit of image uploads from the admin
+	MaxImageSize = 5 * 1024 * 1024
+	// MaxFileSize bounds a Markdown file, far above the longest articles on the site
+	MaxFileSize = 1024 * 1024
+)
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// ImageReader returns the bytes of a local image referenced by an article
+type ImageReader func(path string) ([]byte, error)
+
+// Options control an import
+type Options struct {
+	// DefaultCategory is the category slug for articles whose front matter has none
+	DefaultCategory string
+	// UserID is recorded as author of created posts and editor of changes, 0 when unknown
+	UserID uint
+	// CreateTags allows creating the tags that do not exist yet, otherwise an article
+	// with an unknown tag fails
+	CreateTags bool
+}
+
+// Result is the outcome of importing one file. Status is created, updated, unchanged or
+// failed; a failed import leaves the post as it was.
+type Result struct {
+	File             string           `json:"file"`
+	Status           string           `json:"status"`
+	Error            string           `json:"error,omitempty"`
+	PostID           uint             `json:"post_id,omitempty"`
+	Slug             string           `json:"slug,omitempty"`
+	Images           []string         `json:"images,omitempty"`
+	ContentSanitized *sanitize.Report `json:"content_sanitized,omitempty"`
+	// UnknownTags are the tags that failed the import because Options do not allow creating them
+	UnknownTags []string `json:"unknown_tags,omitempty"`
+}
+
+// unknownTagsError fails an article whose tags do not exist when tags may not be created
+type unknownTagsError []string
+
+func (e unknownTagsError) Error() string {
+	if len(e) == 1 {
+		return fmt.Sprintf("tag %q does not exist", e[0])
+	}
+	quoted := make([]string, len(e))
+	for i, name := range e {
+		quoted[i] = strconv.Quote(name)
+	}
+	return "tags " + strings.Join(quoted, ", ") + " do not exist"
+}
+
+// Import parses a Markdown article, stores its local images and creates the post, or
+// updates the post with the same slug. The slug comes from the front matter or else the
+// title, never with a numeric suffix, so importing the same file again updates the post
+// it created and reports unchanged when nothing differs.
+func Import(file string, source []byte, readImage ImageReader, opts Options) Result {
+	result := Result{File: file, Status: "failed"}
+	stored := map[string]string{}
+	article, err := Parse(source, func(path string) (string, error) {
+		if url, ok := stored[path]; ok {
+			return url, nil
+		}
+		url, err := storeImage(path, readImage)
+		if err != nil {
+			return "", fmt.Errorf("image %s: %w", path, err)
+		}
+		stored[path] = url
+		result.Images = append(result.Images, url)
+		return url, nil
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if article.Category == "" {
+		article.Category = opts.DefaultCategory
+	}
+	result.Slug = slug.Make(article.Slug)
+	if result.Slug == "" {
+		result.Slug = slug.Make(article.Title)
+	}
+	switch {
+	case article.Title == "":
+		err = errors.New("title is required, in the front matter or as a level 1 heading")
+	case article.Category == "":
+		err = errors.New("category is required")
+	case result.Slug == "":
+		err = errors.New("slug is required when the title has no letters or digits")
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	content, report := sanitize.HTML(article.Content)
+	article.Content = content
+	if !report.Empty() {
+		result.ContentSanitized = &report
+	}
+
+	if result.Status, result.PostID, err = savePost(result.Slug, article, opts); err != nil {
+		result.Status, result.Error = "failed", err.Error()
+		var unknown unknownTagsError
+		if errors.As(err, &unknown) {
+			result.UnknownTags = unknown
+		}
+	}
+	return result
+}
+
+// storeImage copies an image into UploadsDir under a name derived from its content, so
+// importing the same image again reuses the file, and returns its URL
+func storeImage(path string, readImage ImageReader) (string, error) {
+	data, err := readImage(path)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > MaxImageSize {
+		return "", errors.New("larger than 5MB")
+	}
+	ext, ok := imageExtensions[http.DetectContentType(data)]
+	if !ok {
+		return "", errors.New("not a JPEG, PNG, GIF or WebP image")
+	}
+
+	sum := sha256.Sum256(data)
+	filename := hex.EncodeToString(sum[:16]) + ext
+	target := filepath.Join(UploadsDir, filename)
+	if _, err := os.Stat(target); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(UploadsDir, 0755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return "", err
+		}
+	}
+	return "/data/uploads/images/" + filename, nil
+}
+
+// postFields are the columns an import writes, comparable to tell whether anything changed
+type postFields struct {
+	Title           string
+	Content         string
+	Summary         string
+	ImageURL        string
+	CategoryID      uint
+	Published       bool
+	MetaTitle       string
+	MetaDescription string
+	FocusKeywords   string
+	OGImageURL      string
+	CanonicalURL    string
+}
+
+// savePost creates or updates the post with postSlug and returns the status and post ID
+func savePost(postSlug string, article Article, opts Options) (string, uint, error) {
+	userID := opts.UserID
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return "", 0, err
+	}
+	defer tx.Rollback()
+
+	fields := postFields{
+		Title: article.Title, Content: article.Content, Summary: article.Summary, ImageURL: article.Image,
+		Published: article.Published, MetaTitle: article.MetaTitle, MetaDescription: article.MetaDescription,
+		FocusKeywords: article.FocusKeywords, OGImageURL: article.OGImage, CanonicalURL: article.CanonicalURL,
+	}
+	err = tx.QueryRow("SELECT id FROM categories WHERE slug = $1 AND deleted_at IS NULL", article.Category).Scan(&fields.CategoryID)
+	if err == sql.ErrNoRows {
+		return "", 0, fmt.Errorf("category %q not found", article.Category)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	tagIDs, err := importTags(tx, article.Tags, opts.CreateTags)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var postID uint
+	var trashed bool
+	var current postFields
+	var currentTags pq.Int64Array
+	err = tx.QueryRow(`SELECT id, deleted_at IS NOT NULL, title, COALESCE(content, ''), COALESCE(summary, ''),
+			COALESCE(image_url, ''), category_id, COALESCE(published, FALSE), COALESCE(meta_title, ''), COALESCE(meta_description, ''),
+			COALESCE(focus_keywords, ''), COALESCE(og_image_url, ''), COALESCE(canonical_url, ''),
+			ARRAY(SELECT tag_id FROM post_tags WHERE post_id = posts.id ORDER BY tag_id)
+		FROM posts WHERE slug = $1 FOR UPDATE`, postSlug).Scan(&postID, &trashed, &current.Title, &current.Content,
+		&current.Summary, &current.ImageURL, &current.CategoryID, &current.Published, &current.MetaTitle,
+		&current.MetaDescription, &current.FocusKeywords, &current.OGImageURL, &current.CanonicalURL, &currentTags)
+	switch {
+	case err == sql.ErrNoRows:
+		postID = 0
+	case err != nil:
+		return "", 0, err
+	case trashed:
+		return "", 0, errors.New("a post with this slug is in the trash, restore or purge it first")
+	}
+
+	status := "created"
+	if postID == 0 {
+		err = tx.QueryRow(`INSERT INTO posts (title, content, summary, image_url, category_id, published,
+				meta_title, meta_description, focus_keywords, og_image_url, canonical_url, slug, views,
+				author_id, last_edited_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, 0, NULLIF($13, 0), NULLIF($13, 0))
+			RETURNING id`, fields.Title, fields.Content, fields.Summary, fields.ImageURL, fields.CategoryID,
+			fields.Published, fields.MetaTitle, fields.MetaDescription, fields.FocusKeywords, fields.OGImageURL,
+			fields.CanonicalURL, postSlug, userID).Scan(&postID)
+		if err != nil {
+			return "", 0, err
+		}
+	} else {
+		if current == fields && sameIDs(currentTags, tagIDs) {
+			return "unchanged", postID, nil
+		}
+
+		status = "updated"
+		if err := database.SaveBaselinePostRevision(tx, postID); err != nil {
+			return "", 0, err
+		}
+		_, err = tx.Exec(`UPDATE posts SET title = $2, content = $3, summary = $4, image_url = $5,
+				category_id = $6, published = $7, meta_title = $8, meta_description = $9, focus_keywords = $10,
+				og_image_url = $11, canonical_url = $12, last_edited_by = NULLIF($13, 0), version = version + 1,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1`, postID, fields.Title, fields.Content, fields.Summary, fields.ImageURL, fields.CategoryID,
+			fields.Published, fields.MetaTitle, fields.MetaDescription, fields.FocusKeywords, fields.OGImageURL,
+			fields.CanonicalURL, userID)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	if err := database.SetPostTags(tx, postID, tagIDs); err != nil {
+		return "", 0, err
+	}
+	if err := database.SavePostRevision(tx, postID, userID, 0); err != nil {
+		return "", 0, err
+	}
+	if err := database.PrunePostRevisions(tx, postID, database.PostRevisionLimit()); err != nil {
+		return "", 0, err
+	}
+	return status, postID, tx.Commit()
+}
+
+// importTags finds the tags with the slugs of names, creating the missing ones when create
+// is set, and returns their IDs in ascending order. Otherwise missing tags are an
+// unknownTagsError listing all of them.
+func importTags(tx *sql.Tx, names []string, create bool) ([]uint, error) {
+	seen := map[uint]bool{}
+	ids := []uint{}
+	var unknown unknownTagsError
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		tagSlug := slug.Make(name)
+		if tagSlug == "" {
+			continue
+		}
+
+		var id uint
+		var err error
+		if create {
+			err = tx.QueryRow(`INSERT INTO tags (name, slug) VALUES ($1, $2)
+				ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+				RETURNING id`, name, tagSlug).Scan(&id)
+		} else {
+			err = tx.QueryRow("SELECT id FROM tags WHERE slug = $1", tagSlug).Scan(&id)
+			if err == sql.ErrNoRows {
+				unknown = append(unknown, name)
+				continue
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tag %q: %w", name, err)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, unknown
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// sameIDs reports whether two ascending ID lists are equal
+func sameIDs(current pq.Int64Array, ids []uint) bool {
+	if len(current) != len(ids) {
+		return false
+	}
+	for i, id := range ids {
+		if current[i] != int64(id) {
+			return false
+		}
+	}
+	return true
+}
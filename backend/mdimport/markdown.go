@@ -0,0 +1,136 @@
+package mdimport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter is the YAML block between --- lines that starts an article. Category is the
+// slug of a category; Tags are tag names, created when missing if Options allow it. Image and OGImage may be
+// local paths like the images in the body.
+type FrontMatter struct {
+	Title           string   `yaml:"title"`
+	Slug            string   `yaml:"slug"`
+	Category        string   `yaml:"category"`
+	Summary         string   `yaml:"summary"`
+	Image           string   `yaml:"image"`
+	MetaTitle       string   `yaml:"meta_title"`
+	MetaDescription string   `yaml:"meta_description"`
+	FocusKeywords   string   `yaml:"focus_keywords"`
+	OGImage         string   `yaml:"og_image"`
+	CanonicalURL    string   `yaml:"canonical_url"`
+	Published       bool     `yaml:"published"`
+	Tags            []string `yaml:"tags"`
+}
+
+// Article is a parsed Markdown file with its body converted to HTML
+type Article struct {
+	FrontMatter
+	Content string
+}
+
+// ImageResolver maps the path of a local image, as written in the file, to the URL it is
+// served from
+type ImageResolver func(path string) (string, error)
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	// Raw HTML such as video embeds is kept, the post sanitize policy decides what stays
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// Parse reads an article from Markdown with optional YAML front matter. Without a title in
+// the front matter, a level 1 heading opening the body becomes the title. Local images in
+// the body and front matter go through resolve; images with a URL are left alone.
+func Parse(source []byte, resolve ImageResolver) (Article, error) {
+	var article Article
+	body, err := splitFrontMatter(source, &article.FrontMatter)
+	if err != nil {
+		return article, err
+	}
+
+	doc := markdown.Parser().Parse(text.NewReader(body))
+	if heading, ok := doc.FirstChild().(*ast.Heading); ok && heading.Level == 1 && article.Title == "" {
+		article.Title = string(heading.Text(body))
+		doc.RemoveChild(doc, heading)
+	}
+	article.Title = strings.TrimSpace(article.Title)
+
+	err = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if image, ok := node.(*ast.Image); ok && entering && isLocalPath(string(image.Destination)) {
+			resolved, err := resolve(string(image.Destination))
+			if err != nil {
+				return ast.WalkStop, err
+			}
+			image.Destination = []byte(resolved)
+		}
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return article, err
+	}
+	for _, field := range []*string{&article.Image, &article.OGImage} {
+		if isLocalPath(*field) {
+			if *field, err = resolve(*field); err != nil {
+				return article, err
+			}
+		}
+	}
+
+	var content bytes.Buffer
+	if err := markdown.Renderer().Render(&content, body, doc); err != nil {
+		return article, err
+	}
+	article.Content = content.String()
+	return article, nil
+}
+
+// splitFrontMatter decodes the front matter at the start of source into frontMatter and
+// returns the Markdown after it. Unknown keys are an error so that typos do not go unnoticed.
+func splitFrontMatter(source []byte, frontMatter *FrontMatter) ([]byte, error) {
+	source = bytes.TrimPrefix(source, []byte("\ufeff"))
+	normalized := bytes.ReplaceAll(source, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(normalized, []byte("---\n")) {
+		return source, nil
+	}
+
+	// rest keeps the newline after the opening --- so that empty front matter closes too
+	rest := normalized[len("---"):]
+	end := bytes.Index(rest, []byte("\n---\n"))
+	if end < 0 {
+		if !bytes.HasSuffix(rest, []byte("\n---")) {
+			return nil, errors.New("front matter is not closed with ---")
+		}
+		end = len(rest) - len("\n---")
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(rest[:end]))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(frontMatter); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("front matter: %w", err)
+	}
+
+	body := rest[end+len("\n---"):]
+	return bytes.TrimPrefix(body, []byte("\n")), nil
+}
+
+// isLocalPath reports whether an image reference is a file path relative to the article
+// rather than a URL or a path on this site
+func isLocalPath(ref string) bool {
+	if ref == "" || strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, "#") {
+		return false
+	}
+	parsed, err := url.Parse(ref)
+	return err != nil || parsed.Scheme == ""
+}
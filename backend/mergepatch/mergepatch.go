@@ -0,0 +1,54 @@
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ErrNotObject is returned when a patch is not a JSON object. Other JSON values are valid
+// merge patches but would replace the whole document, which no endpoint allows.
+var ErrNotObject = errors.New("merge patch must be a JSON object")
+
+// Apply applies an RFC 7386 JSON Merge Patch to a JSON document: members of the patch
+// replace the members of the document with the same name, objects are merged recursively
+// and null removes a member. Arrays are replaced as a whole.
+func Apply(document, patch []byte) ([]byte, error) {
+	var doc, p interface{}
+	if err := decode(document, &doc); err != nil {
+		return nil, err
+	}
+	if err := decode(patch, &p); err != nil {
+		return nil, err
+	}
+	if _, ok := p.(map[string]interface{}); !ok {
+		return nil, ErrNotObject
+	}
+	return json.Marshal(merge(doc, p))
+}
+
+func merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = merge(targetObject[name], value)
+		}
+	}
+	return targetObject
+}
+
+// decode keeps numbers as json.Number so large IDs survive the round trip
+func decode(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -1,38 +1,146 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
+	"house-design-backend/config"
+	"house-design-backend/database"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte("your-secret-key-change-in-production")
-
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid,omitempty"`
+	// Purpose is empty for access tokens and set for single-purpose tokens such as 2FA challenges
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, username string) (string, error) {
+const twoFactorChallengePurpose = "2fa_challenge"
+
+// TwoFactorChallengeTTL is how long a user has to enter their second factor after the password
+const TwoFactorChallengeTTL = 5 * time.Minute
+
+// AccessTokenTTL is how long an access token stays valid (ACCESS_TOKEN_TTL, default 15m)
+func AccessTokenTTL() time.Duration {
+	return config.GetDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+}
+
+// RefreshTokenTTL is how long a session survives without being refreshed (REFRESH_TOKEN_TTL, default 30 days)
+func RefreshTokenTTL() time.Duration {
+	return config.GetDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+func GenerateToken(userID uint, username string, sessionID string) (string, error) {
+	claims := &Claims{
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return signClaims(claims)
+}
+
+// GenerateTwoFactorChallenge issues the short-lived token that proves the password step succeeded
+func GenerateTwoFactorChallenge(userID uint, username string) (string, error) {
 	claims := &Claims{
 		UserID:   userID,
 		Username: username,
+		Purpose:  twoFactorChallengePurpose,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TwoFactorChallengeTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return signClaims(claims)
+}
+
+func signClaims(claims *Claims) (string, error) {
+	if activeKey == nil {
+		return "", errors.New("JWT keys are not initialized")
+	}
+
+	token := jwt.NewWithClaims(activeKey.Method, claims)
+	token.Header["kid"] = activeKey.ID
+	return token.SignedString(activeKey.SignKey)
+}
+
+func parseClaims(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, lookupVerificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// ParseToken validates an access token and returns its claims
+func ParseToken(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionID == "" || claims.Purpose != "" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// ParseTwoFactorChallenge validates a token issued by GenerateTwoFactorChallenge
+func ParseTwoFactorChallenge(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != twoFactorChallengePurpose {
+		return nil, errors.New("invalid challenge token")
+	}
+	return claims, nil
+}
+
+// GenerateRefreshToken returns a random opaque token and the hash to store for it
+func GenerateRefreshToken() (token string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the SHA-256 hex digest used to store opaque tokens
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := apiKeyFromRequest(c); key != "" {
+			authenticateAPIKey(c, key)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
@@ -47,20 +155,54 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := ParseToken(tokenString)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
+		// Load the role and session state on every request so role changes,
+		// deactivation and logout apply immediately
+		var role string
+		var isActive, sessionActive, twoFactorEnabled, twoFactorRequired, mustChangePassword bool
+		err = database.DB.QueryRow(`SELECT a.role, COALESCE(a.is_active, TRUE),
+			EXISTS(SELECT 1 FROM sessions s WHERE s.id = $2 AND s.admin_id = a.id AND s.revoked_at IS NULL AND s.expires_at > NOW()),
+			COALESCE(a.totp_enabled, FALSE),
+			COALESCE((SELECT require_two_factor FROM security_settings ORDER BY id LIMIT 1), FALSE),
+			COALESCE(a.must_change_password, FALSE)
+			FROM admin a WHERE a.id = $1`, claims.UserID, claims.SessionID).Scan(&role, &isActive, &sessionActive, &twoFactorEnabled,
+			&twoFactorRequired, &mustChangePassword)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "User no longer exists"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			}
+			c.Abort()
+			return
+		}
+		if !sessionActive {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+			c.Abort()
+			return
+		}
+		if !isActive {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Account is disabled"})
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("admin_id", claims.UserID) // For backward compatibility
 		c.Set("username", claims.Username)
+		c.Set("session_id", claims.SessionID)
+		c.Set("role", role)
+		// When 2FA is mandatory, unenrolled users may only reach routes without a permission check,
+		// which includes the 2FA enrollment endpoints
+		c.Set("two_factor_setup_required", twoFactorRequired && !twoFactorEnabled)
+		// Same for accounts that still have to replace their initial password
+		c.Set("password_change_required", mustChangePassword)
 		c.Next()
 	}
 }
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is a key that can verify tokens and, for the active key, sign them
+type signingKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	SignKey   interface{} // nil for keys that are only kept for verification
+	VerifyKey interface{}
+}
+
+var (
+	activeKey *signingKey
+	// verificationKeys holds the active key plus every previous key still accepted, by kid
+	verificationKeys = map[string]*signingKey{}
+)
+
+// InitKeys loads the JWT keys from the environment:
+//
+//	JWT_SIGNING_ALG       HS256 (default), EdDSA or RS256
+//	JWT_KEY_ID            kid written into new tokens (default "primary")
+//	JWT_SECRET            shared secret for HS256
+//	JWT_PRIVATE_KEY_FILE  PEM private key for EdDSA/RS256
+//	JWT_PREVIOUS_KEYS     comma-separated kid:ALG:value entries that are still accepted while
+//	                      old tokens expire; value is the secret for HS256 or a PEM public key file
+func InitKeys() error {
+	keyID := strings.TrimSpace(os.Getenv("JWT_KEY_ID"))
+	if keyID == "" {
+		keyID = "primary"
+	}
+
+	alg := strings.TrimSpace(os.Getenv("JWT_SIGNING_ALG"))
+	if alg == "" {
+		alg = jwt.SigningMethodHS256.Alg()
+	}
+
+	key, err := loadActiveKey(keyID, alg)
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]*signingKey{key.ID: key}
+	for _, entry := range strings.Split(os.Getenv("JWT_PREVIOUS_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		previous, err := parsePreviousKey(entry)
+		if err != nil {
+			return err
+		}
+		if _, exists := keys[previous.ID]; exists {
+			return fmt.Errorf("duplicate JWT key id %q", previous.ID)
+		}
+		keys[previous.ID] = previous
+	}
+
+	activeKey = key
+	verificationKeys = keys
+	log.Printf("JWT keys loaded (signing kid=%s alg=%s, %d verification key(s))", key.ID, alg, len(keys))
+	return nil
+}
+
+func loadActiveKey(keyID, alg string) (*signingKey, error) {
+	switch alg {
+	case jwt.SigningMethodHS256.Alg():
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			// Keep development setups working, but never with a guessable secret
+			log.Println("Warning: JWT_SECRET is not set, using a random secret; tokens will not survive a restart")
+			buf := make([]byte, 32)
+			if _, err := rand.Read(buf); err != nil {
+				return nil, err
+			}
+			secret = string(buf)
+		}
+		return &signingKey{ID: keyID, Method: jwt.SigningMethodHS256, SignKey: []byte(secret), VerifyKey: []byte(secret)}, nil
+
+	case jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg():
+		path := os.Getenv("JWT_PRIVATE_KEY_FILE")
+		if path == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", alg)
+		}
+		pemBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT private key: %w", err)
+		}
+
+		if alg == jwt.SigningMethodEdDSA.Alg() {
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Ed25519 private key: %w", err)
+			}
+			edKey, ok := privateKey.(ed25519.PrivateKey)
+			if !ok {
+				return nil, errors.New("invalid Ed25519 private key")
+			}
+			return &signingKey{ID: keyID, Method: jwt.SigningMethodEdDSA, SignKey: edKey, VerifyKey: edKey.Public()}, nil
+		}
+
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA private key: %w", err)
+		}
+		return &signingKey{ID: keyID, Method: jwt.SigningMethodRS256, SignKey: privateKey, VerifyKey: &privateKey.PublicKey}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported JWT_SIGNING_ALG %q", alg)
+}
+
+func parsePreviousKey(entry string) (*signingKey, error) {
+	parts := strings.SplitN(entry, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("invalid JWT_PREVIOUS_KEYS entry %q, expected kid:ALG:value", entry)
+	}
+	keyID, alg, value := parts[0], parts[1], parts[2]
+
+	switch alg {
+	case jwt.SigningMethodHS256.Alg():
+		return &signingKey{ID: keyID, Method: jwt.SigningMethodHS256, VerifyKey: []byte(value)}, nil
+	case jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg():
+		pemBytes, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key for kid %q: %w", keyID, err)
+		}
+		if alg == jwt.SigningMethodEdDSA.Alg() {
+			publicKey, err := jwt.ParseEdPublicKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Ed25519 public key for kid %q: %w", keyID, err)
+			}
+			return &signingKey{ID: keyID, Method: jwt.SigningMethodEdDSA, VerifyKey: publicKey}, nil
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA public key for kid %q: %w", keyID, err)
+		}
+		return &signingKey{ID: keyID, Method: jwt.SigningMethodRS256, VerifyKey: publicKey}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported algorithm %q for kid %q", alg, keyID)
+}
+
+// lookupVerificationKey is the jwt.Keyfunc used when parsing tokens. It picks the key by
+// kid and refuses tokens whose alg does not match that key.
+func lookupVerificationKey(token *jwt.Token) (interface{}, error) {
+	keyID, _ := token.Header["kid"].(string)
+	key, ok := verificationKeys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", keyID)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.VerifyKey, nil
+}
+
+// JSONWebKey is a public verification key in JWK format (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// PublicKeys returns the asymmetric verification keys so other services can verify tokens.
+// Shared HS256 secrets are never published.
+func PublicKeys() []JSONWebKey {
+	keys := []JSONWebKey{}
+	for _, key := range verificationKeys {
+		switch publicKey := key.VerifyKey.(type) {
+		case ed25519.PublicKey:
+			keys = append(keys, JSONWebKey{
+				KeyType: "OKP", KeyID: key.ID, Algorithm: key.Method.Alg(), Use: "sig",
+				Curve: "Ed25519", X: base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		case *rsa.PublicKey:
+			keys = append(keys, JSONWebKey{
+				KeyType: "RSA", KeyID: key.ID, Algorithm: key.Method.Alg(), Use: "sig",
+				N: base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		}
+	}
+	return keys
+}
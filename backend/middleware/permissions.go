@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"net/http"
+
+	"house-design-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permission names an action on the protected API
+type Permission string
+
+const (
+	PermReadAdmin         Permission = "admin:read"
+	PermReadPosts         Permission = "posts:read"
+	PermWritePosts        Permission = "posts:write"
+	PermDeletePosts       Permission = "posts:delete"
+	PermManageCategories  Permission = "categories:manage"
+	PermUploadMedia       Permission = "media:upload"
+	PermManageSiteContent Permission = "site:manage"
+	PermManageSEO         Permission = "seo:manage"
+	PermManageUsers       Permission = "users:manage"
+	PermManageAPIKeys     Permission = "api_keys:manage"
+)
+
+// APIKeyScopes are the permissions that may be granted to an API key. Account and key
+// management are left out so a leaked key cannot escalate itself.
+var APIKeyScopes = []Permission{
+	PermReadPosts, PermWritePosts, PermDeletePosts, PermManageCategories,
+	PermUploadMedia, PermManageSiteContent, PermManageSEO,
+}
+
+// rolePermissions lists what each role is allowed to do
+var rolePermissions = map[string][]Permission{
+	models.RoleOwner: {
+		PermReadAdmin, PermReadPosts, PermWritePosts, PermDeletePosts, PermManageCategories,
+		PermUploadMedia, PermManageSiteContent, PermManageSEO, PermManageUsers, PermManageAPIKeys,
+	},
+	models.RoleEditor: {
+		PermReadAdmin, PermReadPosts, PermWritePosts, PermDeletePosts, PermManageCategories,
+		PermUploadMedia, PermManageSiteContent, PermManageSEO,
+	},
+	models.RoleAuthor: {
+		PermReadAdmin, PermReadPosts, PermWritePosts, PermUploadMedia,
+	},
+	models.RoleViewer: {
+		PermReadAdmin, PermReadPosts,
+	},
+}
+
+// HasPermission reports whether the given role grants perm
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionsForRole returns the permissions granted to role
+func PermissionsForRole(role string) []Permission {
+	return rolePermissions[role]
+}
+
+// IsAPIKeyScope reports whether scope may be granted to an API key
+func IsAPIKeyScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if string(s) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission rejects requests whose authenticated user lacks perm.
+// It must run after AuthMiddleware.
+func RequirePermission(perm Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CheckPermission(c, perm) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// CheckPermission is RequirePermission for handlers whose required permission depends on
+// the request. It aborts with 403 and returns false when the user lacks perm.
+func CheckPermission(c *gin.Context, perm Permission) bool {
+	if c.GetBool("password_change_required") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "The password must be changed before continuing",
+			"code":  "password_change_required",
+		})
+		c.Abort()
+		return false
+	}
+	if c.GetBool("two_factor_setup_required") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Two-factor authentication must be set up before continuing",
+			"code":  "two_factor_setup_required",
+		})
+		c.Abort()
+		return false
+	}
+	// API keys are limited to their scopes on top of their creator's role
+	if scopes, ok := c.Get("api_key_scopes"); ok && !hasScope(scopes.([]string), perm) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key is missing the " + string(perm) + " scope"})
+		c.Abort()
+		return false
+	}
+	if !HasPermission(c.GetString("role"), perm) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+// Allows reports whether the authenticated user, and the API key when one is used, grant
+// perm. Unlike CheckPermission it does not respond, for parts of a request that are only
+// done with the permission.
+func Allows(c *gin.Context, perm Permission) bool {
+	if scopes, ok := c.Get("api_key_scopes"); ok && !hasScope(scopes.([]string), perm) {
+		return false
+	}
+	return HasPermission(c.GetString("role"), perm)
+}
+
+// RequireUserSession rejects API keys on routes that act on the logged-in account itself,
+// such as sessions, password and 2FA management
+func RequireUserSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("api_key_id"); ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This endpoint requires a user login"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func hasScope(scopes []string, perm Permission) bool {
+	for _, scope := range scopes {
+		if scope == string(perm) {
+			return true
+		}
+	}
+	return false
+}
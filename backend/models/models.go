@@ -2,12 +2,45 @@ package models
 
 import (
 	"time"
+
+	"house-design-backend/diff"
+	"house-design-backend/sanitize"
+)
+
+// Admin roles, from most to least privileged
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleAuthor = "author"
+	RoleViewer = "viewer"
 )
 
+// IsValidRole reports whether role is one of the known admin roles
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleOwner, RoleEditor, RoleAuthor, RoleViewer:
+		return true
+	}
+	return false
+}
+
 type Admin struct {
-	ID       uint   `json:"id" gorm:"primaryKey"`
-	Username string `json:"username" gorm:"unique;not null"`
-	Password string `json:"-" gorm:"not null"` // "-" excludes from JSON
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Username    string    `json:"username" gorm:"unique;not null"`
+	Password    string    `json:"-" gorm:"not null"` // "-" excludes from JSON
+	Email       string    `json:"email"`
+	DisplayName string    `json:"display_name"`
+	// Public byline profile
+	AvatarURL   string    `json:"avatar_url"`
+	Bio         string    `json:"bio"`
+	Role        string    `json:"role" gorm:"default:'editor'"`
+	IsActive    bool      `json:"is_active" gorm:"default:true"`
+	// TOTP second factor; the secret never leaves the server after enrollment
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+	// Set on the seeded default account; permissioned routes are blocked until the password is changed
+	MustChangePassword bool      `json:"must_change_password"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
 }
 
 type Category struct {
@@ -29,14 +62,16 @@ type Category struct {
 	MetaDescription string `json:"meta_description"`
 	MetaKeywords    string `json:"meta_keywords"`
 	OGImageURL      string `json:"og_image_url"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	// Version is incremented by every edit and sent back in If-Match
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Post struct {
 	ID         uint      `json:"id" gorm:"primaryKey"`
 	Title      string    `json:"title" gorm:"not null"`
-	Content    string    `json:"content" gorm:"type:text"`
+	Content    string    `json:"content,omitempty" gorm:"type:text"` // left out of ?view=list listings
 	Summary    string    `json:"summary"`
 	ImageURL   string    `json:"image_url"`
 	CategoryID uint      `json:"category_id" gorm:"not null"`
@@ -48,11 +83,220 @@ type Post struct {
 	MetaDescription string `json:"meta_description"`
 	FocusKeywords   string `json:"focus_keywords"`
 	OGImageURL      string `json:"og_image_url"`
+	CanonicalURL    string `json:"canonical_url"`
 	Slug            string `json:"slug"`
+	// Scheduling: the post goes live at PublishAt and is taken down at UnpublishAt
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	Tags        []Tag      `json:"tags"`
+	// Authorship, set by the server from the logged-in user
+	AuthorID     *uint   `json:"author_id"`
+	Author       *Author `json:"author,omitempty"`
+	LastEditedBy *uint   `json:"last_edited_by"`
+	// TagIDs assigns tags in create and update requests; left out, an update keeps the current tags
+	TagIDs *[]uint `json:"tag_ids,omitempty"`
+	// ContentSanitized reports what was stripped from the content, only in the response to
+	// a create or update that stripped something
+	ContentSanitized *sanitize.Report `json:"content_sanitized,omitempty"`
+	// Version is incremented by every edit and sent back in If-Match
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+
+// Author is the public byline of a user. Usernames and emails are never exposed.
+type Author struct {
+	ID          uint   `json:"id"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+	Bio         string `json:"bio"`
+}
+
+// AuthorResponse is an author profile with one page of their published posts
+type AuthorResponse struct {
+	Author Author `json:"author"`
+	PostListResponse
+}
+
+// Tag is a free-form label on posts. PostCount, the number of published posts, is only
+// filled in tag listings.
+type Tag struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	PostCount   *int      `json:"post_count,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TagRequest creates or updates a tag; an empty slug is generated from the name
+type TagRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+}
+
+// ScheduledPostChange is an upcoming publish or unpublish of a post
+type ScheduledPostChange struct {
+	PostID    uint      `json:"post_id"`
+	Title     string    `json:"title"`
+	Slug      string    `json:"slug"`
+	Published bool      `json:"published"`
+	Action    string    `json:"action"` // "publish" or "unpublish"
+	At        time.Time `json:"at"`
+}
+
+// PostRevision is a saved version of a post. Content is left out of revision listings.
+type PostRevision struct {
+	ID              uint      `json:"id"`
+	PostID          uint      `json:"post_id"`
+	Revision        int       `json:"revision"`
+	Title           string    `json:"title"`
+	Summary         string    `json:"summary"`
+	Content         string    `json:"content,omitempty"`
+	ImageURL        string    `json:"image_url"`
+	MetaTitle       string    `json:"meta_title"`
+	MetaDescription string    `json:"meta_description"`
+	FocusKeywords   string    `json:"focus_keywords"`
+	OGImageURL      string    `json:"og_image_url"`
+	EditorID        *uint     `json:"editor_id"`
+	EditorUsername  string    `json:"editor_username,omitempty"`
+	RestoredFrom    *int      `json:"restored_from,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
 }
 
+// PostRevisionFieldDiff holds the line changes of one field between two revisions
+type PostRevisionFieldDiff struct {
+	Field  string       `json:"field"`
+	Chunks []diff.Chunk `json:"chunks"`
+}
+
+// PostRevisionDiff compares two revisions of a post; only changed fields are listed
+type PostRevisionDiff struct {
+	PostID uint                    `json:"post_id"`
+	From   PostRevision            `json:"from"`
+	To     PostRevision            `json:"to"`
+	Fields []PostRevisionFieldDiff `json:"fields"`
+}
+
+// PostListResponse is one page of a post listing. NextCursor is empty on the last page.
+type PostListResponse struct {
+	Posts      []Post `json:"posts"`
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// BulkPostRequest applies one action to a list of posts. CategoryID is the target of move,
+// TagIDs the tags of add_tags and remove_tags. With Atomic set, one failed post rolls back
+// the changes to every post.
+type BulkPostRequest struct {
+	Action     string `json:"action" binding:"required"` // publish, unpublish, move, add_tags, remove_tags or delete
+	IDs        []uint `json:"ids" binding:"required"`
+	CategoryID uint   `json:"category_id"`
+	TagIDs     []uint `json:"tag_ids"`
+	Atomic     bool   `json:"atomic"`
+}
+
+// BulkPostResult is the outcome of a bulk action for one post: ok, not_found, failed, or
+// rolled_back when it succeeded but an atomic request failed elsewhere
+type BulkPostResult struct {
+	ID     uint   `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkPostResponse struct {
+	Action     string           `json:"action"`
+	Succeeded  int              `json:"succeeded"`
+	Failed     int              `json:"failed"`
+	RolledBack bool             `json:"rolled_back"`
+	Results    []BulkPostResult `json:"results"`
+}
+
+// TrashedPost is a post in the trash. PurgeAt is when it will be deleted for good.
+type TrashedPost struct {
+	ID           uint      `json:"id"`
+	Title        string    `json:"title"`
+	Slug         string    `json:"slug"`
+	CategoryID   uint      `json:"category_id"`
+	CategoryName string    `json:"category_name"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	PurgeAt      time.Time `json:"purge_at"`
+}
+
+// TrashedCategory is a category in the trash. PostCount is the number of posts that were
+// trashed with it and come back when it is restored.
+type TrashedCategory struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	ParentID  *uint     `json:"parent_id"`
+	PostCount int       `json:"post_count"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// CategoryDeletionPreview reports what deleting a category would affect. Only content that
+// is not already in the trash is counted.
+type CategoryDeletionPreview struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	// PostCount counts the posts directly in the category, TotalPostCount adds the posts of
+	// every subcategory at any depth
+	PostCount        int                     `json:"post_count"`
+	SubcategoryCount int                     `json:"subcategory_count"`
+	TotalPostCount   int                     `json:"total_post_count"`
+	Children         []CategoryDeletionChild `json:"children"`
+	Empty            bool                    `json:"empty"`
+}
+
+// CategoryDeletionChild is a direct subcategory in a deletion preview, with the
+// subcategories and posts below it
+type CategoryDeletionChild struct {
+	ID               uint   `json:"id"`
+	Name             string `json:"name"`
+	Slug             string `json:"slug"`
+	SubcategoryCount int    `json:"subcategory_count"`
+	PostCount        int    `json:"post_count"`
+}
+
+type TrashResponse struct {
+	Posts      []TrashedPost     `json:"posts"`
+	Categories []TrashedCategory `json:"categories"`
+}
+
+// SearchResult is a post matching a search. TitleHighlight and Snippet are HTML-escaped
+// with the matched words wrapped in <mark>.
+type SearchResult struct {
+	Post           Post    `json:"post"`
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+type CategoryFacet struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Count int    `json:"count"`
+}
+
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Total   int            `json:"total"`
+	Limit   int            `json:"limit"`
+	Offset  int            `json:"offset"`
+	Results []SearchResult `json:"results"`
+	// Match counts per category, ignoring the ?category= filter so clients can switch between them
+	Facets struct {
+		Categories []CategoryFacet `json:"categories"`
+	} `json:"facets"`
+}
 
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
@@ -60,8 +304,157 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
-	Admin Admin  `json:"admin"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // access token lifetime in seconds
+	Admin        Admin  `json:"admin"`
+}
+
+// TwoFactorChallengeResponse is returned by login instead of tokens when the account uses 2FA
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // TOTP code or recovery code
+}
+
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// SecuritySettings holds site-wide authentication policy
+type SecuritySettings struct {
+	RequireTwoFactor bool      `json:"require_two_factor"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ForgotPasswordRequest identifies the account by email or username
+type ForgotPasswordRequest struct {
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// APIKey authenticates a machine client. Only a hash of the key is stored; Prefix is kept
+// so keys can be told apart in listings.
+type APIKey struct {
+	ID                uint       `json:"id"`
+	Name              string     `json:"name"`
+	Prefix            string     `json:"prefix"`
+	Scopes            []string   `json:"scopes"`
+	CreatedBy         uint       `json:"created_by"`
+	CreatedByUsername string     `json:"created_by_username"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+	LastUsedAt        *time.Time `json:"last_used_at"`
+	LastUsedIP        string     `json:"last_used_ip"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil means the key never expires
+}
+
+// CreateAPIKeyResponse carries the plaintext key, which is only ever shown once
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// LoginAttempt is one recorded call to the login endpoint
+type LoginAttempt struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoginLockout is a username or IP address that is currently throttled
+type LoginLockout struct {
+	Key           string    `json:"key"` // "user:<username>" or "ip:<address>"
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+	LockedUntil   time.Time `json:"locked_until"`
+}
+
+type UnlockLoginRequest struct {
+	Username  string `json:"username"`
+	IPAddress string `json:"ip_address"`
+}
+
+// Session is a logged-in device, identified by its rotating refresh token
+type Session struct {
+	ID         string    `json:"id"`
+	AdminID    uint      `json:"admin_id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+type CreateUserRequest struct {
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+	Email       string `json:"email"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+	Bio         string `json:"bio"`
+	Role        string `json:"role" binding:"required"`
+}
+
+// UpdateProfileRequest changes the byline of the logged-in user; only present fields change
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name"`
+	AvatarURL   *string `json:"avatar_url"`
+	Bio         *string `json:"bio"`
+}
+
+// UpdateUserRequest only changes the fields that are present
+type UpdateUserRequest struct {
+	Email       *string `json:"email"`
+	DisplayName *string `json:"display_name"`
+	AvatarURL   *string `json:"avatar_url"`
+	Bio         *string `json:"bio"`
+	Role        *string `json:"role"`
+	IsActive    *bool   `json:"is_active"`
+	Password    *string `json:"password"`
 }
 
 type CategoryOrderUpdate struct {
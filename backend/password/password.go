@@ -0,0 +1,69 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"house-design-backend/config"
+)
+
+// commonPasswords are rejected regardless of length or character mix
+var commonPasswords = map[string]bool{
+	"admin123": true, "admin1234": true, "administrator": true, "password": true, "password1": true,
+	"password123": true, "12345678": true, "123456789": true, "1234567890": true, "qwerty123": true,
+	"abc12345": true, "iloveyou": true, "11111111": true, "00000000": true, "letmein123": true,
+	"welcome123": true, "matkhau123": true, "admin@123": true, "p@ssw0rd": true, "p@ssword1": true,
+	"password1!": true, "password@123": true, "qwerty@123": true,
+}
+
+// MinLength is the minimum password length, configurable with PASSWORD_MIN_LENGTH
+func MinLength() int {
+	return config.GetInt("PASSWORD_MIN_LENGTH", 10)
+}
+
+// Validate checks a new password against the strength rules: a minimum length, at least
+// three of lower case, upper case, digits and symbols, not a well-known password and not
+// containing the username.
+func Validate(password, username string) error {
+	if minLength := MinLength(); len([]rune(password)) < minLength {
+		return fmt.Errorf("password must be at least %d characters", minLength)
+	}
+	if len(password) > 72 {
+		// bcrypt ignores everything after 72 bytes
+		return errors.New("password must be at most 72 bytes")
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	if classes < 3 {
+		return errors.New("password must contain at least three of: lower case letters, upper case letters, digits, symbols")
+	}
+
+	lowered := strings.ToLower(password)
+	if commonPasswords[lowered] {
+		return errors.New("password is too common")
+	}
+	if username = strings.ToLower(strings.TrimSpace(username)); len(username) >= 3 && strings.Contains(lowered, username) {
+		return errors.New("password must not contain the username")
+	}
+	return nil
+}
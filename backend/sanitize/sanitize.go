@@ -0,0 +1,152 @@
+package sanitize
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"house-design-backend/config"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+)
+
+// The default allowlist covers what the CKEditor toolbar in the admin produces: headings,
+// inline formatting, links, lists, quotes, images, tables and media embeds
+var (
+	defaultElements = []string{
+		"p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6", "div", "span",
+		"strong", "b", "em", "i", "u", "s", "del", "ins", "sub", "sup", "mark", "small", "code", "pre",
+		"a", "ul", "ol", "li", "blockquote", "figure", "figcaption", "img",
+		"table", "caption", "colgroup", "col", "thead", "tbody", "tfoot", "tr", "th", "td",
+	}
+	defaultURLSchemes  = []string{"http", "https", "mailto", "tel"}
+	defaultIframeHosts = []string{"www.youtube.com", "youtube.com", "www.youtube-nocookie.com", "youtu.be"}
+)
+
+var (
+	policy     *bluemonday.Policy
+	policyOnce sync.Once
+)
+
+// Policy is the allowlist applied to post content. On top of the defaults it allows the
+// elements in HTML_ALLOWED_ELEMENTS and the attributes in HTML_ALLOWED_ATTRIBUTES, each
+// either "attr" on every element or "element:attr". Links and images may use the schemes
+// in HTML_URL_SCHEMES or be relative. Embeds, an <iframe src> or the <oembed url> CKEditor
+// writes for media, must point at one of HTML_IFRAME_HOSTS over https.
+func Policy() *bluemonday.Policy {
+	policyOnce.Do(func() {
+		policy = newPolicy()
+	})
+	return policy
+}
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements(defaultElements...)
+	p.AllowAttrs("class").Matching(bluemonday.SpaceSeparatedTokens).Globally()
+	p.AllowAttrs("title", "lang").Globally()
+	p.AllowAttrs("dir").Matching(bluemonday.Direction).Globally()
+
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("target").Matching(regexp.MustCompile(`^_blank$`)).OnElements("a")
+	p.AllowAttrs("rel").Matching(bluemonday.SpaceSeparatedTokens).OnElements("a")
+	p.AllowAttrs("src", "srcset", "sizes", "alt").OnElements("img")
+	p.AllowAttrs("width", "height").Matching(bluemonday.NumberOrPercent).OnElements("img", "iframe", "table", "col")
+	p.AllowAttrs("colspan", "rowspan").Matching(bluemonday.Integer).OnElements("th", "td")
+	p.AllowAttrs("scope").Matching(regexp.MustCompile(`^(row|col|rowgroup|colgroup)$`)).OnElements("th")
+	p.AllowAttrs("span").Matching(bluemonday.Integer).OnElements("col", "colgroup")
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowAttrs("reversed").OnElements("ol")
+	p.AllowAttrs("value").Matching(bluemonday.Integer).OnElements("li")
+	p.AllowAttrs("cite").OnElements("blockquote")
+
+	embed := embedURLPattern(config.GetList("HTML_IFRAME_HOSTS", defaultIframeHosts))
+	p.AllowElements("iframe", "oembed")
+	p.AllowAttrs("src").Matching(embed).OnElements("iframe")
+	p.AllowAttrs("url").Matching(embed).OnElements("oembed")
+	p.AllowAttrs("allowfullscreen").OnElements("iframe")
+	p.AllowAttrs("frameborder").Matching(bluemonday.Integer).OnElements("iframe")
+	p.AllowAttrs("allow").Matching(regexp.MustCompile(`^[a-z-]+(; ?[a-z-]+)*;?$`)).OnElements("iframe")
+
+	p.AllowElements(config.GetList("HTML_ALLOWED_ELEMENTS", nil)...)
+	for _, attr := range config.GetList("HTML_ALLOWED_ATTRIBUTES", nil) {
+		if element, name, found := strings.Cut(attr, ":"); found {
+			p.AllowAttrs(name).OnElements(element)
+		} else {
+			p.AllowAttrs(attr).Globally()
+		}
+	}
+
+	p.AllowURLSchemes(config.GetList("HTML_URL_SCHEMES", defaultURLSchemes)...)
+	p.AllowRelativeURLs(true)
+	p.RequireParseableURLs(true)
+	return p
+}
+
+// embedURLPattern matches https URLs on one of hosts
+func embedURLPattern(hosts []string) *regexp.Regexp {
+	quoted := make([]string, len(hosts))
+	for i, host := range hosts {
+		quoted[i] = regexp.QuoteMeta(strings.ToLower(host))
+	}
+	return regexp.MustCompile(`^https://(` + strings.Join(quoted, "|") + `)(/|$)`)
+}
+
+// Report lists what sanitizing removed. Elements are counted by name, attributes as
+// "element[attribute]"; an attribute is also counted when its element was removed.
+type Report struct {
+	RemovedElements   map[string]int `json:"removed_elements,omitempty"`
+	RemovedAttributes map[string]int `json:"removed_attributes,omitempty"`
+}
+
+// Empty reports whether nothing was removed
+func (r Report) Empty() bool {
+	return len(r.RemovedElements) == 0 && len(r.RemovedAttributes) == 0
+}
+
+// HTML sanitizes content with Policy and reports what it removed. Content the policy
+// allows comes back equivalent, though entities and attribute quoting may be normalized.
+func HTML(content string) (string, Report) {
+	clean := Policy().Sanitize(content)
+
+	elements, attributes := countMarkup(content)
+	cleanElements, cleanAttributes := countMarkup(clean)
+	return clean, Report{
+		RemovedElements:   removedCounts(elements, cleanElements),
+		RemovedAttributes: removedCounts(attributes, cleanAttributes),
+	}
+}
+
+// countMarkup counts the start tags and their attributes in a fragment of HTML
+func countMarkup(content string) (map[string]int, map[string]int) {
+	elements := map[string]int{}
+	attributes := map[string]int{}
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return elements, attributes
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			elements[token.Data]++
+			for _, attr := range token.Attr {
+				attributes[token.Data+"["+attr.Key+"]"]++
+			}
+		}
+	}
+}
+
+// removedCounts returns how many of each key before has that after does not, nil if none
+func removedCounts(before, after map[string]int) map[string]int {
+	var removed map[string]int
+	for key, count := range before {
+		if diff := count - after[key]; diff > 0 {
+			if removed == nil {
+				removed = map[string]int{}
+			}
+			removed[key] = diff
+		}
+	}
+	return removed
+}
@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+)
+
+// Every runs job in the background every interval, starting one interval from now.
+// Jobs must be safe to run from several server instances at once, usually by taking a
+// PostgreSQL advisory lock; errors are logged and the job is retried on the next tick.
+func Every(name string, interval time.Duration, job func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			run(name, job)
+		}
+	}()
+	log.Printf("Scheduled job %q every %s", name, interval)
+}
+
+// run calls job, keeping a panic from taking the whole server down
+func run(name string, job func() error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Scheduled job %q panicked: %v", name, r)
+		}
+	}()
+
+	if err := job(); err != nil {
+		log.Printf("Scheduled job %q failed: %v", name, err)
+	}
+}
@@ -0,0 +1,79 @@
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"house-design-backend/config"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations covers Latin letters that do not decompose into an ASCII base letter
+// plus combining marks, đ being the one that matters for Vietnamese
+var transliterations = map[rune]string{
+	'đ': "d", 'Đ': "d", 'ð': "d", 'Ð': "d",
+	'ß': "ss", 'æ': "ae", 'Æ': "ae", 'œ': "oe", 'Œ': "oe",
+	'ø': "o", 'Ø': "o", 'ł': "l", 'Ł': "l", 'þ': "th", 'Þ': "th",
+	'ı': "i", 'ħ': "h", 'Ħ': "h", 'ŧ': "t", 'Ŧ': "t",
+}
+
+// MaxLength is the longest slug Make produces, configurable with SLUG_MAX_LENGTH
+func MaxLength() int {
+	return config.GetInt("SLUG_MAX_LENGTH", 80)
+}
+
+// Make converts text into a URL slug: diacritics are stripped ("Mẫu Thiết Kế" becomes
+// "mau-thiet-ke"), everything other than ASCII letters and digits turns into a single
+// hyphen, and the result is cut to MaxLength at a word boundary when possible. The result
+// is empty when text has no usable characters.
+func Make(text string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range norm.NFD.String(text) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+
+		var part string
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			part = string(unicode.ToLower(r))
+		case transliterations[r] != "":
+			part = transliterations[r]
+		case r == '\'' || r == '’':
+			// "nhà's" reads better as "nhas" than "nha-s"
+			continue
+		default:
+			pendingHyphen = b.Len() > 0
+			continue
+		}
+
+		if pendingHyphen {
+			b.WriteByte('-')
+			pendingHyphen = false
+		}
+		b.WriteString(part)
+	}
+
+	return truncate(b.String(), MaxLength())
+}
+
+// WithSuffix appends -n to slug, shortening slug first so the result still fits MaxLength
+func WithSuffix(slug string, n int) string {
+	suffix := "-" + strconv.Itoa(n)
+	return truncate(slug, MaxLength()-len(suffix)) + suffix
+}
+
+// truncate cuts s to at most max bytes, preferring the last hyphen so words stay whole
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	if i := strings.LastIndexByte(s, '-'); i > max/2 {
+		s = s[:i]
+	}
+	return strings.Trim(s, "-")
+}
@@ -0,0 +1,94 @@
+package viewcounter
+
+import (
+	"crypto/sha256"
+	"regexp"
+	"sync"
+	"time"
+
+	"house-design-backend/config"
+	"house-design-backend/database"
+)
+
+// botUserAgent matches crawlers, link previewers and headless browsers
+var botUserAgent = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|archiver|facebookexternalhit|embedly|preview|` +
+	`bingpreview|headless|phantomjs|lighthouse|pingdom|uptime|monitor|curl|wget|python-requests|go-http-client|httpclient|scrapy`)
+
+// visit identifies one visitor viewing one post; the visitor is a hash of IP and user agent
+type visit struct {
+	visitor [16]byte
+	postID  uint
+}
+
+var (
+	mu sync.Mutex
+	// pending holds the views counted since the last flush, by post ID
+	pending = map[uint]int{}
+	// seen holds when a visitor's view of a post stops being a repeat, by visitor and post
+	seen = map[visit]time.Time{}
+)
+
+// DedupWindow is how long repeat views of a post by the same visitor are ignored,
+// configurable with VIEW_DEDUP_WINDOW
+func DedupWindow() time.Duration {
+	return config.GetDuration("VIEW_DEDUP_WINDOW", 30*time.Minute)
+}
+
+// IsBot reports whether a user agent belongs to a crawler or an automated client.
+// Requests without a user agent are treated as bots.
+func IsBot(userAgent string) bool {
+	return userAgent == "" || botUserAgent.MatchString(userAgent)
+}
+
+// Record counts a view of a post unless the visitor, identified by IP and user agent, is a
+// bot or already viewed the post within DedupWindow. Counts are kept in memory until Flush.
+func Record(postID uint, ip, userAgent string) bool {
+	if IsBot(userAgent) {
+		return false
+	}
+
+	key := visit{postID: postID}
+	sum := sha256.Sum256([]byte(ip + "\x00" + userAgent))
+	copy(key.visitor[:], sum[:])
+	now := time.Now()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if until, ok := seen[key]; ok && now.Before(until) {
+		return false
+	}
+	seen[key] = now.Add(DedupWindow())
+	pending[postID]++
+	return true
+}
+
+// Flush writes the buffered counts to PostgreSQL in one statement and forgets expired
+// visitors. Counts that fail to be written are kept for the next flush.
+func Flush() error {
+	now := time.Now()
+
+	mu.Lock()
+	counts := pending
+	pending = map[uint]int{}
+	for key, until := range seen {
+		if now.After(until) {
+			delete(seen, key)
+		}
+	}
+	mu.Unlock()
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	if err := database.AddPostViews(counts); err != nil {
+		mu.Lock()
+		for postID, count := range counts {
+			pending[postID] += count
+		}
+		mu.Unlock()
+		return err
+	}
+	return nil
+}
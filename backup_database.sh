@@ -102,7 +102,7 @@ if [ \$? -eq 0 ]; then
     psql -h "\$DB_HOST" -p "\$DB_PORT" -U "\$DB_USER" -d "\$DB_NAME" << SQL
 SELECT 'Categories: ' || COUNT(*) FROM categories;
 SELECT 'Posts: ' || COUNT(*) FROM posts;
-SELECT 'Articles: ' || COUNT(*) FROM articles;
+SELECT 'Tags: ' || COUNT(*) FROM tags;
 SQL
 else
     echo "❌ Failed to restore backup to VPS!"
@@ -118,7 +118,7 @@ EOF
     echo "📊 Backup statistics:"
     echo "Categories: $(psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" -t -c "SELECT COUNT(*) FROM categories;")"
     echo "Posts: $(psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" -t -c "SELECT COUNT(*) FROM posts;")"
-    echo "Articles: $(psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" -t -c "SELECT COUNT(*) FROM articles;")"
+    echo "Tags: $(psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" -t -c "SELECT COUNT(*) FROM tags;")"
 
 else
     echo ""
@@ -22,6 +22,10 @@ export const routes: Routes = [
     path: 'admin/login',
     loadComponent: () => import('./pages/admin-login/admin-login.component').then(c => c.AdminLoginComponent)
   },
+  {
+    path: 'admin/reset-password',
+    loadComponent: () => import('./pages/reset-password/reset-password.component').then(c => c.ResetPasswordComponent)
+  },
   {
     path: 'admin',
     canActivate: [authGuard],
@@ -14,6 +14,7 @@ import { MatSnackBar } from '@angular/material/snack-bar';
 import { Observable } from 'rxjs';
 import { DataService } from '../../services/data.service';
 import { Category } from '../../models/models';
+import { SlugUtil } from '../../utils/slug.util';
 
 @Component({
   selector: 'app-category-dialog',
@@ -393,22 +394,12 @@ export class CategoryDialogComponent implements OnInit {
     // Auto-generate slug from name
     this.categoryForm.get('name')?.valueChanges.subscribe(name => {
       if (name && !this.data.category) {
-        const slug = this.generateSlug(name);
+        const slug = SlugUtil.generate(name);
         this.categoryForm.get('slug')?.setValue(slug);
       }
     });
   }
 
-  private generateSlug(name: string): string {
-    return name
-      .toLowerCase()
-      .normalize('NFD')
-      .replace(/[\u0300-\u036f]/g, '') // Remove diacritics
-      .replace(/[^a-z0-9\s-]/g, '') // Remove special characters
-      .trim()
-      .replace(/\s+/g, '-'); // Replace spaces with hyphens
-  }
-
   onCancel(): void {
     this.dialogRef.close();
   }
@@ -471,7 +462,7 @@ export class CategoryDialogComponent implements OnInit {
       });
 
       const operation = this.data.category
-        ? this.dataService.updateCategory(this.data.category.id, categoryData)
+        ? this.dataService.updateCategory(this.data.category.id, categoryData, this.data.category.version)
         : this.dataService.createCategory(categoryData);
 
       operation.subscribe({
@@ -495,6 +486,10 @@ export class CategoryDialogComponent implements OnInit {
         },
         error: (error) => {
           this.isLoading = false;
+          if (error.status === 412) {
+            this.snackBar.open('Danh mục vừa được người khác chỉnh sửa. Vui lòng tải lại trước khi lưu.', 'Đóng', { duration: 5000 });
+            return;
+          }
           this.snackBar.open('Có lỗi xảy ra!', 'Đóng', { duration: 3000 });
         }
       });
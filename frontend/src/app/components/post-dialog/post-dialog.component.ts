@@ -14,8 +14,9 @@ import ClassicEditor from '@ckeditor/ckeditor5-build-classic';
 import { Observable } from 'rxjs';
 import { DataService } from '../../services/data.service';
 import { AuthService } from '../../services/auth.service';
-import { Category, Post } from '../../models/models';
+import { Category, Post, Tag } from '../../models/models';
 import { CKEditorUploadAdapterPlugin } from '../../utils/ckeditor-upload-adapter';
+import { SlugUtil } from '../../utils/slug.util';
 
 @Component({
   selector: 'app-post-dialog',
@@ -58,6 +59,15 @@ import { CKEditorUploadAdapterPlugin } from '../../utils/ckeditor-upload-adapter
           </mat-error>
         </mat-form-field>
 
+        <mat-form-field appearance="fill" class="full-width">
+          <mat-label>Thẻ</mat-label>
+          <mat-select formControlName="tag_ids" multiple>
+            <mat-option *ngFor="let tag of tags$ | async" [value]="tag.id">
+              {{ tag.name }}
+            </mat-option>
+          </mat-select>
+        </mat-form-field>
+
         <mat-form-field appearance="fill" class="full-width">
           <mat-label>Tóm tắt</mat-label>
           <textarea matInput formControlName="summary" rows="3" placeholder="Nhập tóm tắt bài viết"></textarea>
@@ -161,11 +171,32 @@ import { CKEditorUploadAdapterPlugin } from '../../utils/ckeditor-upload-adapter
                    placeholder="Image URL for social media sharing">
             <mat-hint>Recommended size: 1200x630px (leave blank to use post image)</mat-hint>
           </mat-form-field>
+
+          <mat-form-field appearance="outline" class="full-width">
+            <mat-label>Canonical URL</mat-label>
+            <input matInput formControlName="canonical_url"
+                   placeholder="https://...">
+            <mat-hint>Leave blank unless this content is published elsewhere first</mat-hint>
+          </mat-form-field>
         </div>
 
         <mat-checkbox formControlName="published" class="published-checkbox">
           Xuất bản ngay
         </mat-checkbox>
+
+        <div class="schedule-section">
+          <mat-form-field appearance="outline" class="full-width">
+            <mat-label>Lên lịch xuất bản</mat-label>
+            <input matInput type="datetime-local" formControlName="publish_at">
+            <mat-hint>Bài viết tự động hiển thị vào thời điểm này</mat-hint>
+          </mat-form-field>
+
+          <mat-form-field appearance="outline" class="full-width">
+            <mat-label>Hết hạn hiển thị</mat-label>
+            <input matInput type="datetime-local" formControlName="unpublish_at">
+            <mat-hint>Bài viết tự động ẩn vào thời điểm này</mat-hint>
+          </mat-form-field>
+        </div>
       </form>
     </mat-dialog-content>
 
@@ -380,6 +411,7 @@ export class PostDialogComponent implements OnInit {
   postForm: FormGroup;
   isLoading = false;
   categories$: Observable<Category[]>;
+  tags$: Observable<Tag[]>;
 
   // Image upload properties
   selectedImageUrl: string | null = null;
@@ -400,19 +432,24 @@ export class PostDialogComponent implements OnInit {
     this.postForm = this.fb.group({
       title: ['', [Validators.required]],
       category_id: ['', [Validators.required]],
+      tag_ids: [[]],
       summary: [''],
       image_url: [''],
       content: ['', [Validators.required]],
       published: [false],
+      publish_at: [''],
+      unpublish_at: [''],
       // SEO Fields
       meta_title: [''],
       meta_description: [''],
       focus_keywords: [''],
       slug: [''],
-      og_image_url: ['']
+      og_image_url: [''],
+      canonical_url: ['']
     });
 
-    this.categories$ = this.dataService.getCategories();
+    this.categories$ = this.dataService.getAdminCategories();
+    this.tags$ = this.dataService.getTags();
   }
 
   ngOnInit(): void {
@@ -474,16 +511,20 @@ export class PostDialogComponent implements OnInit {
       this.postForm.patchValue({
         title: post.title,
         category_id: post.category_id,
+        tag_ids: (post.tags || []).map(tag => tag.id),
         summary: post.summary,
         image_url: post.image_url,
         content: this.convertContentImageUrls(post.content),
         published: post.published,
+        publish_at: this.toDateTimeLocal(post.publish_at),
+        unpublish_at: this.toDateTimeLocal(post.unpublish_at),
         // SEO Fields
         meta_title: post.meta_title || '',
         meta_description: post.meta_description || '',
         focus_keywords: post.focus_keywords || '',
         slug: post.slug || '',
-        og_image_url: post.og_image_url || ''
+        og_image_url: post.og_image_url || '',
+        canonical_url: post.canonical_url || ''
       });
       
       
@@ -500,24 +541,35 @@ export class PostDialogComponent implements OnInit {
   onSave(): void {
     if (this.postForm.valid) {
       this.isLoading = true;
-      const postData = { ...this.postForm.value };
+      const postData = {
+        ...this.postForm.value,
+        publish_at: this.fromDateTimeLocal(this.postForm.value.publish_at),
+        unpublish_at: this.fromDateTimeLocal(this.postForm.value.unpublish_at)
+      };
 
       const operation = this.data.post
-        ? this.dataService.updatePost(this.data.post.id, postData)
+        ? this.dataService.updatePost(this.data.post.id, postData, this.data.post.version)
         : this.dataService.createPost(postData);
 
       operation.subscribe({
         next: (result) => {
           this.isLoading = false;
-          this.snackBar.open(
-            this.data.post ? 'Cập nhật bài viết thành công!' : 'Thêm bài viết thành công!',
-            'Đóng',
-            { duration: 3000 }
-          );
+          const message = this.data.post ? 'Cập nhật bài viết thành công!' : 'Thêm bài viết thành công!';
+          if (result.content_sanitized) {
+            // The server removed markup it does not allow, e.g. scripts or embeds from unknown sites
+            const removed = Object.keys({ ...result.content_sanitized.removed_elements, ...result.content_sanitized.removed_attributes });
+            this.snackBar.open(`${message} Một số mã HTML không được phép đã bị loại bỏ: ${removed.join(', ')}`, 'Đóng', { duration: 8000 });
+          } else {
+            this.snackBar.open(message, 'Đóng', { duration: 3000 });
+          }
           this.dialogRef.close(result);
         },
         error: (error) => {
           this.isLoading = false;
+          if (error.status === 412) {
+            this.snackBar.open('Bài viết vừa được người khác chỉnh sửa. Vui lòng tải lại trước khi lưu.', 'Đóng', { duration: 5000 });
+            return;
+          }
           this.snackBar.open('Có lỗi xảy ra!', 'Đóng', { duration: 3000 });
         }
       });
@@ -636,16 +688,22 @@ export class PostDialogComponent implements OnInit {
     return metaDescription.length;
   }
 
+  // datetime-local inputs work in the browser's time zone, the API in absolute instants
+  private toDateTimeLocal(value?: string | null): string {
+    if (!value) return '';
+    const date = new Date(value);
+    const pad = (n: number) => n.toString().padStart(2, '0');
+    return `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(date.getDate())}T${pad(date.getHours())}:${pad(date.getMinutes())}`;
+  }
+
+  private fromDateTimeLocal(value: string): string | null {
+    return value ? new Date(value).toISOString() : null;
+  }
+
   generateSlug(): void {
     const title = this.postForm.get('title')?.value;
     if (title) {
-      const slug = title
-        .toLowerCase()
-        .replace(/[^a-z0-9\s-]/g, '')
-        .replace(/\s+/g, '-')
-        .replace(/-+/g, '-')
-        .trim();
-      this.postForm.patchValue({ slug });
+      this.postForm.patchValue({ slug: SlugUtil.generate(title) });
     }
   }
 }
\ No newline at end of file
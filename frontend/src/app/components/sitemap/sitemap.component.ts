@@ -57,7 +57,7 @@ export class SitemapComponent implements OnInit {
         // Add posts (only published ones)
         posts.filter(post => post.published).forEach(post => {
           urls.push({
-            loc: `${baseUrl}/post/${post.id}`,
+            loc: `${baseUrl}/post/${post.slug || post.id}`,
             lastmod: post.updated_at ? new Date(post.updated_at).toISOString().split('T')[0] : new Date(post.created_at || '').toISOString().split('T')[0],
             changefreq: 'monthly',
             priority: '0.6'
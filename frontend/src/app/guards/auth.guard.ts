@@ -7,6 +7,11 @@ export const authGuard = () => {
   const router = inject(Router);
 
   if (authService.isAuthenticated()) {
+    // The initial password has to be replaced first, the login page hosts that form
+    if (authService.getCurrentUser()?.must_change_password) {
+      router.navigate(['/admin/login']);
+      return false;
+    }
     return true;
   } else {
     router.navigate(['/admin/login']);
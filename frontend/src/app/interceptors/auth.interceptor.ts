@@ -1,17 +1,25 @@
-import { HttpInterceptorFn } from '@angular/common/http';
+import { HttpErrorResponse, HttpInterceptorFn, HttpRequest } from '@angular/common/http';
 import { inject } from '@angular/core';
+import { catchError, switchMap, throwError } from 'rxjs';
 import { AuthService } from '../services/auth.service';
 
+const withToken = (req: HttpRequest<unknown>, token: string | null) =>
+  token ? req.clone({ headers: req.headers.set('Authorization', `Bearer ${token}`) }) : req;
+
 export const authInterceptor: HttpInterceptorFn = (req, next) => {
   const authService = inject(AuthService);
-  const token = authService.getToken();
-
-  if (token) {
-    const authReq = req.clone({
-      headers: req.headers.set('Authorization', `Bearer ${token}`)
-    });
-    return next(authReq);
-  }
+  const isAuthCall = ['/auth/login', '/auth/refresh', '/auth/2fa/verify', '/auth/reset-password']
+    .some(path => req.url.includes(path));
 
-  return next(req);
+  return next(withToken(req, authService.getToken())).pipe(
+    catchError((error: HttpErrorResponse) => {
+      // Access tokens are short-lived: refresh once and replay the request
+      if (error.status !== 401 || isAuthCall || !authService.getRefreshToken()) {
+        return throwError(() => error);
+      }
+      return authService.refreshToken().pipe(
+        switchMap(response => next(withToken(req, response.token)))
+      );
+    })
+  );
 };
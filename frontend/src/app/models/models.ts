@@ -1,6 +1,14 @@
 export interface Admin {
   id: number;
   username: string;
+  email?: string;
+  display_name?: string;
+  avatar_url?: string;
+  bio?: string;
+  role?: 'owner' | 'editor' | 'author' | 'viewer';
+  is_active?: boolean;
+  two_factor_enabled?: boolean;
+  must_change_password?: boolean;
 }
 
 export interface Category {
@@ -22,6 +30,7 @@ export interface Category {
   meta_description?: string;
   meta_keywords?: string;
   og_image_url?: string;
+  version?: number; // sent back in If-Match when saving
   created_at?: string;
   updated_at?: string;
 }
@@ -41,11 +50,208 @@ export interface Post {
   meta_description?: string;
   focus_keywords?: string;
   og_image_url?: string;
+  canonical_url?: string;
   slug?: string;
+  publish_at?: string | null;
+  unpublish_at?: string | null;
+  tags?: Tag[];
+  tag_ids?: number[];
+  author_id?: number | null;
+  author?: Author;
+  last_edited_by?: number | null;
+  content_sanitized?: SanitizeReport; // only in save responses that stripped something
+  version?: number; // sent back in If-Match when saving
   created_at?: string;
   updated_at?: string;
 }
 
+export interface Author {
+  id: number;
+  display_name: string;
+  avatar_url: string;
+  bio: string;
+}
+
+export interface AuthorResponse extends PostListResponse {
+  author: Author;
+}
+
+export interface Tag {
+  id: number;
+  name: string;
+  slug: string;
+  description: string;
+  post_count?: number;
+  created_at?: string;
+  updated_at?: string;
+}
+
+export interface TagRequest {
+  name: string;
+  slug?: string;
+  description?: string;
+}
+
+export interface ScheduledPostChange {
+  post_id: number;
+  title: string;
+  slug: string;
+  published: boolean;
+  action: 'publish' | 'unpublish';
+  at: string;
+}
+
+export interface PostRevision {
+  id: number;
+  post_id: number;
+  revision: number;
+  title: string;
+  summary: string;
+  content?: string;
+  image_url: string;
+  meta_title: string;
+  meta_description: string;
+  focus_keywords: string;
+  og_image_url: string;
+  editor_id: number | null;
+  editor_username?: string;
+  restored_from?: number;
+  created_at: string;
+}
+
+export interface DiffChunk {
+  op: 'equal' | 'insert' | 'delete';
+  lines: string[];
+}
+
+export interface PostRevisionDiff {
+  post_id: number;
+  from: PostRevision;
+  to: PostRevision;
+  fields: { field: string; chunks: DiffChunk[] }[];
+}
+
+export interface PostListResponse {
+  posts: Post[];
+  total: number;
+  limit: number;
+  offset: number;
+  next_cursor?: string;
+}
+
+export interface BulkPostRequest {
+  action: 'publish' | 'unpublish' | 'move' | 'add_tags' | 'remove_tags' | 'delete';
+  ids: number[];
+  category_id?: number; // move
+  tag_ids?: number[]; // add_tags, remove_tags
+  atomic?: boolean;
+}
+
+export interface BulkPostResponse {
+  action: string;
+  succeeded: number;
+  failed: number;
+  rolled_back: boolean;
+  results: { id: number; status: 'ok' | 'not_found' | 'failed' | 'rolled_back'; error?: string }[];
+}
+
+// What the server stripped from post content: element names and element[attribute] pairs with counts
+export interface SanitizeReport {
+  removed_elements?: Record<string, number>;
+  removed_attributes?: Record<string, number>;
+}
+
+export interface MarkdownImportResult {
+  file: string;
+  status: 'created' | 'updated' | 'unchanged' | 'failed';
+  error?: string;
+  post_id?: number;
+  slug?: string;
+  images?: string[]; // URLs of the local images stored for the post
+  content_sanitized?: SanitizeReport;
+}
+
+export interface MarkdownImportResponse {
+  results: MarkdownImportResult[];
+  created: number;
+  updated: number;
+  unchanged: number;
+  failed: number;
+}
+
+export type CategoryDeleteStrategy = 'block' | 'reassign' | 'cascade';
+
+// Counts only content that is not already in the trash
+export interface CategoryDeletionPreview {
+  id: number;
+  name: string;
+  slug: string;
+  post_count: number; // posts directly in the category
+  subcategory_count: number;
+  total_post_count: number; // including posts of every subcategory
+  children: { id: number; name: string; slug: string; subcategory_count: number; post_count: number }[];
+  empty: boolean;
+}
+
+// Trashed items are purged for good at purge_at
+export interface TrashedPost {
+  id: number;
+  title: string;
+  slug: string;
+  category_id: number;
+  category_name: string;
+  deleted_at: string;
+  purge_at: string;
+}
+
+export interface TrashedCategory {
+  id: number;
+  name: string;
+  slug: string;
+  parent_id?: number | null;
+  post_count: number; // posts trashed with the category, restored with it
+  deleted_at: string;
+  purge_at: string;
+}
+
+export interface TrashResponse {
+  posts: TrashedPost[];
+  categories: TrashedCategory[];
+}
+
+// title_highlight and snippet are escaped HTML with matches wrapped in <mark>
+export interface SearchResult {
+  post: Post;
+  rank: number;
+  title_highlight: string;
+  snippet: string;
+}
+
+export interface SearchResponse {
+  query: string;
+  total: number;
+  limit: number;
+  offset: number;
+  results: SearchResult[];
+  facets: {
+    categories: { id: number; name: string; slug: string; count: number }[];
+  };
+}
+
+export interface PostListQuery {
+  category?: number;
+  include_children?: boolean;
+  tag?: string;
+  published?: boolean;
+  from?: string;
+  to?: string;
+  sort?: 'newest' | 'oldest' | 'most_viewed' | 'title';
+  limit?: number;
+  offset?: number;
+  cursor?: string;
+  view?: 'list';
+}
+
 export interface LoginRequest {
   username: string;
   password: string;
@@ -53,9 +259,18 @@ export interface LoginRequest {
 
 export interface LoginResponse {
   token: string;
+  refresh_token: string;
+  expires_in: number;
   admin: Admin;
 }
 
+// Returned by login instead of tokens when the account has two-factor authentication enabled
+export interface TwoFactorChallengeResponse {
+  two_factor_required: true;
+  challenge_token: string;
+  expires_in: number;
+}
+
 export interface CreateCategoryRequest {
   name: string;
   slug: string;
@@ -9,7 +9,7 @@ import { MatButtonModule } from '@angular/material/button';
 import { MatIconModule } from '@angular/material/icon';
 import { MatSnackBar, MatSnackBarModule } from '@angular/material/snack-bar';
 import { AuthService } from '../../services/auth.service';
-import { LoginRequest } from '../../models/models';
+import { Admin, LoginRequest } from '../../models/models';
 
 @Component({
   selector: 'app-admin-login',
@@ -36,7 +36,7 @@ import { LoginRequest } from '../../models/models';
                 <p>Nhập thông tin để truy cập trang quản trị</p>
               </div>
               
-              <form (ngSubmit)="onLogin()" #loginForm="ngForm" class="login-form">
+              <form *ngIf="step === 'login'" (ngSubmit)="onLogin()" #loginForm="ngForm" class="login-form">
                 <mat-form-field appearance="outline" class="full-width">
                   <mat-label>Tên đăng nhập</mat-label>
                   <input matInput 
@@ -72,6 +72,75 @@ import { LoginRequest } from '../../models/models';
                   <span>{{ isLoading ? 'Đang đăng nhập...' : 'Đăng Nhập' }}</span>
                 </button>
               </form>
+
+              <form *ngIf="step === 'two_factor'" (ngSubmit)="onVerifyCode()" #codeForm="ngForm" class="login-form">
+                <p>Nhập mã 6 số từ ứng dụng xác thực hoặc một mã khôi phục</p>
+                <mat-form-field appearance="outline" class="full-width">
+                  <mat-label>Mã xác thực</mat-label>
+                  <input matInput
+                         [(ngModel)]="twoFactorCode"
+                         name="code"
+                         required
+                         autocomplete="one-time-code">
+                  <mat-icon matSuffix>lock</mat-icon>
+                </mat-form-field>
+
+                <button mat-raised-button
+                        type="submit"
+                        class="login-btn full-width"
+                        [disabled]="!codeForm.valid || isLoading"
+                        color="primary">
+                  <span>{{ isLoading ? 'Đang xác thực...' : 'Xác Thực' }}</span>
+                </button>
+                <button mat-button type="button" (click)="cancelTwoFactor()">Quay lại</button>
+              </form>
+
+              <form *ngIf="step === 'change_password'" (ngSubmit)="onChangePassword()" #passwordForm="ngForm" class="login-form">
+                <p>Bạn cần đổi mật khẩu mặc định trước khi tiếp tục</p>
+                <mat-form-field appearance="outline" class="full-width">
+                  <mat-label>Mật khẩu hiện tại</mat-label>
+                  <input matInput type="password" [(ngModel)]="currentPassword" name="currentPassword" required autocomplete="current-password">
+                </mat-form-field>
+                <mat-form-field appearance="outline" class="full-width">
+                  <mat-label>Mật khẩu mới</mat-label>
+                  <input matInput type="password" [(ngModel)]="newPassword" name="newPassword" required autocomplete="new-password">
+                  <mat-hint>Ít nhất 10 ký tự, gồm 3 trong 4 loại: chữ thường, chữ hoa, số, ký hiệu</mat-hint>
+                </mat-form-field>
+                <mat-form-field appearance="outline" class="full-width">
+                  <mat-label>Nhập lại mật khẩu mới</mat-label>
+                  <input matInput type="password" [(ngModel)]="confirmPassword" name="confirmPassword" required autocomplete="new-password">
+                </mat-form-field>
+
+                <button mat-raised-button
+                        type="submit"
+                        class="login-btn full-width"
+                        [disabled]="!passwordForm.valid || isLoading"
+                        color="primary">
+                  <span>{{ isLoading ? 'Đang lưu...' : 'Đổi Mật Khẩu' }}</span>
+                </button>
+              </form>
+
+              <form *ngIf="step === 'forgot'" (ngSubmit)="onForgotPassword()" #forgotForm="ngForm" class="login-form">
+                <p>Nhập email của tài khoản để nhận liên kết đặt lại mật khẩu</p>
+                <mat-form-field appearance="outline" class="full-width">
+                  <mat-label>Email</mat-label>
+                  <input matInput type="email" [(ngModel)]="forgotEmail" name="email" required autocomplete="email">
+                  <mat-icon matSuffix>email</mat-icon>
+                </mat-form-field>
+
+                <button mat-raised-button
+                        type="submit"
+                        class="login-btn full-width"
+                        [disabled]="!forgotForm.valid || isLoading"
+                        color="primary">
+                  <span>{{ isLoading ? 'Đang gửi...' : 'Gửi Liên Kết' }}</span>
+                </button>
+                <button mat-button type="button" (click)="step = 'login'">Quay lại</button>
+              </form>
+
+              <button *ngIf="step === 'login'" mat-button type="button" class="full-width" (click)="step = 'forgot'">
+                Quên mật khẩu?
+              </button>
               
               <div class="login-info">
                 <p><strong>Thông tin đăng nhập mặc định:</strong></p>
@@ -200,6 +269,13 @@ export class AdminLoginComponent {
   
   hidePassword = true;
   isLoading = false;
+  step: 'login' | 'two_factor' | 'change_password' | 'forgot' = 'login';
+  challengeToken: string | null = null;
+  twoFactorCode = '';
+  currentPassword = '';
+  newPassword = '';
+  confirmPassword = '';
+  forgotEmail = '';
 
   constructor(
     private authService: AuthService,
@@ -208,7 +284,11 @@ export class AdminLoginComponent {
   ) {
     // If already logged in, redirect to admin
     if (this.authService.isAuthenticated()) {
-      this.router.navigate(['/admin']);
+      if (this.authService.getCurrentUser()?.must_change_password) {
+        this.step = 'change_password';
+      } else {
+        this.router.navigate(['/admin']);
+      }
     }
   }
 
@@ -222,11 +302,12 @@ export class AdminLoginComponent {
     this.authService.login(this.credentials).subscribe({
       next: (response) => {
         this.isLoading = false;
-        this.snackBar.open('Đăng nhập thành công!', 'Đóng', {
-          duration: 3000,
-          panelClass: ['success-snackbar']
-        });
-        this.router.navigate(['/admin']);
+        if ('two_factor_required' in response) {
+          this.challengeToken = response.challenge_token;
+          this.step = 'two_factor';
+          return;
+        }
+        this.onLoggedIn(response.admin);
       },
       error: (error) => {
         this.isLoading = false;
@@ -234,6 +315,8 @@ export class AdminLoginComponent {
         
         if (error.status === 401) {
           errorMessage = 'Tên đăng nhập hoặc mật khẩu không đúng!';
+        } else if (error.status === 429) {
+          errorMessage = 'Đăng nhập sai quá nhiều lần, vui lòng thử lại sau!';
         } else if (error.status === 0) {
           errorMessage = 'Không thể kết nối đến server!';
         }
@@ -245,4 +328,114 @@ export class AdminLoginComponent {
       }
     });
   }
+
+  onVerifyCode(): void {
+    if (!this.challengeToken || !this.twoFactorCode) {
+      return;
+    }
+
+    this.isLoading = true;
+
+    this.authService.verifyTwoFactor(this.challengeToken, this.twoFactorCode).subscribe({
+      next: (response) => {
+        this.isLoading = false;
+        this.onLoggedIn(response.admin);
+      },
+      error: (error) => {
+        this.isLoading = false;
+        this.twoFactorCode = '';
+        let errorMessage = 'Mã xác thực không đúng!';
+
+        if (error.status === 429) {
+          errorMessage = 'Nhập sai quá nhiều lần, vui lòng thử lại sau!';
+        } else if (error.status === 401 && error.error?.error !== 'Invalid verification code') {
+          // The challenge expired, start over from the password step
+          this.cancelTwoFactor();
+          errorMessage = 'Phiên xác thực đã hết hạn, vui lòng đăng nhập lại!';
+        }
+
+        this.snackBar.open(errorMessage, 'Đóng', {
+          duration: 5000,
+          panelClass: ['error-snackbar']
+        });
+      }
+    });
+  }
+
+  cancelTwoFactor(): void {
+    this.step = 'login';
+    this.challengeToken = null;
+    this.twoFactorCode = '';
+  }
+
+  onChangePassword(): void {
+    if (this.newPassword !== this.confirmPassword) {
+      this.snackBar.open('Mật khẩu nhập lại không khớp!', 'Đóng', {
+        duration: 5000,
+        panelClass: ['error-snackbar']
+      });
+      return;
+    }
+
+    this.isLoading = true;
+
+    this.authService.changePassword(this.currentPassword, this.newPassword).subscribe({
+      next: () => {
+        this.isLoading = false;
+        this.currentPassword = this.newPassword = this.confirmPassword = '';
+        this.snackBar.open('Đổi mật khẩu thành công!', 'Đóng', {
+          duration: 3000,
+          panelClass: ['success-snackbar']
+        });
+        this.router.navigate(['/admin']);
+      },
+      error: (error) => {
+        this.isLoading = false;
+        this.snackBar.open(error.error?.error || 'Đổi mật khẩu thất bại!', 'Đóng', {
+          duration: 5000,
+          panelClass: ['error-snackbar']
+        });
+      }
+    });
+  }
+
+  onForgotPassword(): void {
+    this.isLoading = true;
+
+    this.authService.forgotPassword(this.forgotEmail).subscribe({
+      next: () => {
+        this.isLoading = false;
+        this.step = 'login';
+        this.snackBar.open('Nếu email tồn tại, liên kết đặt lại mật khẩu đã được gửi!', 'Đóng', {
+          duration: 5000,
+          panelClass: ['success-snackbar']
+        });
+      },
+      error: (error) => {
+        this.isLoading = false;
+        const errorMessage = error.status === 429
+          ? 'Yêu cầu quá nhiều lần, vui lòng thử lại sau!'
+          : error.status === 503
+            ? 'Máy chủ chưa cấu hình gửi email, vui lòng liên hệ quản trị viên!'
+            : 'Không thể gửi yêu cầu!';
+        this.snackBar.open(errorMessage, 'Đóng', {
+          duration: 5000,
+          panelClass: ['error-snackbar']
+        });
+      }
+    });
+  }
+
+  private onLoggedIn(admin: Admin): void {
+    if (admin.must_change_password) {
+      this.currentPassword = this.credentials.password;
+      this.step = 'change_password';
+      return;
+    }
+    this.snackBar.open('Đăng nhập thành công!', 'Đóng', {
+      duration: 3000,
+      panelClass: ['success-snackbar']
+    });
+    this.router.navigate(['/admin']);
+  }
 }
@@ -141,10 +141,10 @@ export class AdminComponent implements OnInit {
     private router: Router,
     private logger: LoggerService
   ) {
-    this.categories$ = this.dataService.getCategories();
+    this.categories$ = this.dataService.getAdminCategories();
 
     // Process posts with URL conversion
-    this.posts$ = this.dataService.getPosts().pipe(
+    this.posts$ = this.dataService.getAdminPosts().pipe(
       map(posts => this.processPostImageUrls(posts))
     );
 
@@ -184,7 +184,7 @@ export class AdminComponent implements OnInit {
     return this.refreshSubject.pipe(
       startWith(undefined),
       switchMap(() =>
-        this.dataService.getCategories().pipe(
+        this.dataService.getAdminCategories().pipe(
           map(categories => {
             this.logger.logCategoryOperation('loaded', { count: categories.length });
             console.log('🔎 Raw categories from API:', categories);
@@ -239,7 +239,7 @@ export class AdminComponent implements OnInit {
         category: category || undefined,
         isSubcategory,
         parentId: parentId || undefined,
-        allCategories: this.dataService.getCategories()
+        allCategories: this.dataService.getAdminCategories()
       }
     });
 
@@ -253,7 +253,7 @@ export class AdminComponent implements OnInit {
     if (result) {
       this.logger.logCategoryOperation('dialog completed successfully');
       this.refreshData();
-      this.categories$ = this.dataService.getCategories();
+      this.categories$ = this.dataService.getAdminCategories();
     } else {
       this.logger.debug('Category dialog cancelled by user', undefined, 'CategoryManagement');
     }
@@ -264,18 +264,32 @@ export class AdminComponent implements OnInit {
   }
 
   deleteCategory(id: number): void {
-    if (confirm('Bạn có chắc chắn muốn xóa danh mục này?')) {
-      this.dataService.deleteCategory(id).subscribe({
-        next: () => {
-          this.refreshData();
-          this.showSuccessMessage('Danh mục đã được xóa');
-        },
-        error: (error) => {
-          this.logger.error('Error deleting category', error, 'CategoryManagement');
-          this.showErrorMessage('Lỗi khi xóa danh mục');
+    // Preview first so that a category with content is only deleted with explicit consent
+    this.dataService.getCategoryDeletionPreview(id).subscribe({
+      next: (preview) => {
+        const message = preview.empty
+          ? 'Bạn có chắc chắn muốn xóa danh mục này?'
+          : `Danh mục "${preview.name}" có ${preview.subcategory_count} danh mục con và ${preview.total_post_count} bài viết. ` +
+            'Xóa danh mục sẽ chuyển toàn bộ nội dung này vào thùng rác. Bạn có chắc chắn?';
+        if (!confirm(message)) {
+          return;
         }
-      });
-    }
+        this.dataService.deleteCategory(id, preview.empty ? 'block' : 'cascade').subscribe({
+          next: () => {
+            this.refreshData();
+            this.showSuccessMessage('Danh mục đã được chuyển vào thùng rác');
+          },
+          error: (error) => {
+            this.logger.error('Error deleting category', error, 'CategoryManagement');
+            this.showErrorMessage(error.status === 409 ? 'Danh mục vừa có thêm nội dung, vui lòng thử lại' : 'Lỗi khi xóa danh mục');
+          }
+        });
+      },
+      error: (error) => {
+        this.logger.error('Error previewing category deletion', error, 'CategoryManagement');
+        this.showErrorMessage('Lỗi khi xóa danh mục');
+      }
+    });
   }
 
   toggleCategory(category: CategoryTreeItem): void {
@@ -381,7 +395,7 @@ export class AdminComponent implements OnInit {
   }
 
   private refreshPostsList(): void {
-    this.posts$ = this.dataService.getPosts().pipe(
+    this.posts$ = this.dataService.getAdminPosts().pipe(
       map(posts => this.processPostImageUrls(posts))
     );
   }
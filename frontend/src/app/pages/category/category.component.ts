@@ -40,7 +40,7 @@ import { Post, Admin } from '../../models/models';
 
         <!-- Posts Grid -->
         <div class="posts-grid" *ngIf="posts$ | async as posts; else noPosts">
-          <mat-card class="post-card" *ngFor="let post of posts" [routerLink]="'/post/' + post.id">
+          <mat-card class="post-card" *ngFor="let post of posts" [routerLink]="'/post/' + (post.slug || post.id)">
             <div class="post-image">
               <img
                 [src]="post.image_url || 'assets/images/placeholder-post.jpg'"
@@ -47,6 +47,10 @@ import { Post, Admin } from '../../models/models';
                 <mat-icon>event</mat-icon>
                 <span>{{ post.created_at | date:'dd/MM/yyyy HH:mm' }}</span>
               </div>
+              <div class="meta-item" *ngIf="post.author?.display_name">
+                <mat-icon>person</mat-icon>
+                <span>{{ post.author?.display_name }}</span>
+              </div>
               <div class="meta-item" *ngIf="post.category">
                 <mat-icon>category</mat-icon>
                 <span>{{ post.category.name }}</span>
@@ -470,30 +474,27 @@ export class PostDetailComponent implements OnInit {
     private authService: AuthService
   ) {
     this.post$ = this.route.params.pipe(
-      switchMap(params => {
-        const id = parseInt(params['id']);
-        return this.dataService.getPost(id);
-      })
+      switchMap(params => this.dataService.getPostByKey(params['id']))
     );
     this.currentUser$ = this.authService.currentUser$;
   }
 
   ngOnInit(): void {
     this.route.params.subscribe(params => {
-      const id = parseInt(params['id']);
+      const key = params['id'];
 
-      if (id) {
+      if (key) {
         this.isLoading = true;
         this.hasError = false;
         this.post = null;
 
-        this.dataService.getPost(id).subscribe({
+        this.dataService.getPostByKey(key).subscribe({
           next: (post) => {
             this.isLoading = false;
             this.post = post;
             this.hasError = false;
             // Increment view count
-            this.dataService.incrementPostViews(id).subscribe({
+            this.dataService.incrementPostViews(post.id).subscribe({
               next: () => console.log('View count incremented'),
               error: (error) => console.error('Error incrementing views:', error)
             });
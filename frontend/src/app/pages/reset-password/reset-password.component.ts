@@ -0,0 +1,157 @@
+import { Component } from '@angular/core';
+import { CommonModule } from '@angular/common';
+import { FormsModule } from '@angular/forms';
+import { ActivatedRoute, Router } from '@angular/router';
+import { MatCardModule } from '@angular/material/card';
+import { MatFormFieldModule } from '@angular/material/form-field';
+import { MatInputModule } from '@angular/material/input';
+import { MatButtonModule } from '@angular/material/button';
+import { MatIconModule } from '@angular/material/icon';
+import { MatSnackBar, MatSnackBarModule } from '@angular/material/snack-bar';
+import { AuthService } from '../../services/auth.service';
+
+@Component({
+  selector: 'app-reset-password',
+  standalone: true,
+  imports: [
+    CommonModule,
+    FormsModule,
+    MatCardModule,
+    MatFormFieldModule,
+    MatInputModule,
+    MatButtonModule,
+    MatIconModule,
+    MatSnackBarModule
+  ],
+  template: `
+    <div class="reset-page">
+      <mat-card class="reset-card">
+        <mat-card-content>
+          <div class="reset-header">
+            <mat-icon class="reset-icon">lock_reset</mat-icon>
+            <h2>Đặt Lại Mật Khẩu</h2>
+          </div>
+
+          <p *ngIf="!token" class="reset-error">Liên kết không hợp lệ. Vui lòng yêu cầu liên kết mới.</p>
+
+          <form *ngIf="token" (ngSubmit)="onSubmit()" #resetForm="ngForm" class="reset-form">
+            <mat-form-field appearance="outline" class="full-width">
+              <mat-label>Mật khẩu mới</mat-label>
+              <input matInput type="password" [(ngModel)]="newPassword" name="newPassword" required autocomplete="new-password">
+              <mat-hint>Ít nhất 10 ký tự, gồm 3 trong 4 loại: chữ thường, chữ hoa, số, ký hiệu</mat-hint>
+            </mat-form-field>
+            <mat-form-field appearance="outline" class="full-width">
+              <mat-label>Nhập lại mật khẩu mới</mat-label>
+              <input matInput type="password" [(ngModel)]="confirmPassword" name="confirmPassword" required autocomplete="new-password">
+            </mat-form-field>
+
+            <button mat-raised-button
+                    type="submit"
+                    class="full-width"
+                    [disabled]="!resetForm.valid || isLoading"
+                    color="primary">
+              {{ isLoading ? 'Đang lưu...' : 'Đặt Lại Mật Khẩu' }}
+            </button>
+          </form>
+        </mat-card-content>
+      </mat-card>
+    </div>
+  `,
+  styles: [`
+    .reset-page {
+      min-height: 100vh;
+      background: linear-gradient(135deg, var(--primary-blue), var(--light-blue));
+      display: flex;
+      align-items: center;
+      justify-content: center;
+      padding: 20px;
+    }
+
+    .reset-card {
+      width: 100%;
+      max-width: 400px;
+      border-radius: 16px;
+      box-shadow: 0 10px 30px rgba(0, 0, 0, 0.3);
+    }
+
+    .reset-header {
+      text-align: center;
+      margin-bottom: 20px;
+    }
+
+    .reset-icon {
+      font-size: 3rem;
+      width: 3rem;
+      height: 3rem;
+      color: var(--primary-blue);
+    }
+
+    .reset-header h2 {
+      color: var(--dark-blue);
+      font-weight: 600;
+    }
+
+    .reset-form {
+      display: flex;
+      flex-direction: column;
+      gap: 20px;
+    }
+
+    .reset-error {
+      color: var(--text-secondary);
+      text-align: center;
+    }
+
+    .full-width {
+      width: 100%;
+    }
+  `]
+})
+export class ResetPasswordComponent {
+  token: string | null;
+  newPassword = '';
+  confirmPassword = '';
+  isLoading = false;
+
+  constructor(
+    route: ActivatedRoute,
+    private authService: AuthService,
+    private router: Router,
+    private snackBar: MatSnackBar
+  ) {
+    this.token = route.snapshot.queryParamMap.get('token');
+  }
+
+  onSubmit(): void {
+    if (!this.token) {
+      return;
+    }
+    if (this.newPassword !== this.confirmPassword) {
+      this.snackBar.open('Mật khẩu nhập lại không khớp!', 'Đóng', {
+        duration: 5000,
+        panelClass: ['error-snackbar']
+      });
+      return;
+    }
+
+    this.isLoading = true;
+
+    this.authService.resetPassword(this.token, this.newPassword).subscribe({
+      next: () => {
+        this.isLoading = false;
+        this.snackBar.open('Đặt lại mật khẩu thành công, vui lòng đăng nhập!', 'Đóng', {
+          duration: 5000,
+          panelClass: ['success-snackbar']
+        });
+        this.router.navigate(['/admin/login']);
+      },
+      error: (error) => {
+        this.isLoading = false;
+        this.snackBar.open(error.error?.error || 'Đặt lại mật khẩu thất bại!', 'Đóng', {
+          duration: 5000,
+          panelClass: ['error-snackbar']
+        });
+      }
+    });
+  }
+}
@@ -1,7 +1,7 @@
 import { Injectable } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
-import { BehaviorSubject, Observable, tap } from 'rxjs';
-import { LoginRequest, LoginResponse, Admin } from '../models/models';
+import { BehaviorSubject, Observable, finalize, shareReplay, tap } from 'rxjs';
+import { LoginRequest, LoginResponse, TwoFactorChallengeResponse, Admin } from '../models/models';
 import { environment } from '../../environments/environment';
 
 @Injectable({
@@ -10,32 +10,91 @@ import { environment } from '../../environments/environment';
 export class AuthService {
   private apiUrl = environment.apiUrl;
   private tokenKey = 'auth_token';
+  private refreshTokenKey = 'refresh_token';
   private userKey = 'current_user';
+  private refreshInFlight: Observable<LoginResponse> | null = null;
   
   private currentUserSubject = new BehaviorSubject<Admin | null>(this.getCurrentUser());
   public currentUser$ = this.currentUserSubject.asObservable();
 
   constructor(private http: HttpClient) {}
 
-  login(credentials: LoginRequest): Observable<LoginResponse> {
-    return this.http.post<LoginResponse>(`${this.apiUrl}/auth/login`, credentials)
+  login(credentials: LoginRequest): Observable<LoginResponse | TwoFactorChallengeResponse> {
+    return this.http.post<LoginResponse | TwoFactorChallengeResponse>(`${this.apiUrl}/auth/login`, credentials)
       .pipe(
         tap(response => {
-          localStorage.setItem(this.tokenKey, response.token);
-          localStorage.setItem(this.userKey, JSON.stringify(response.admin));
-          this.currentUserSubject.next(response.admin);
+          if (!('two_factor_required' in response)) {
+            this.storeSession(response);
+          }
         })
       );
   }
 
+  // Completes a login that returned a two-factor challenge with a TOTP or recovery code
+  verifyTwoFactor(challengeToken: string, code: string): Observable<LoginResponse> {
+    return this.http.post<LoginResponse>(`${this.apiUrl}/auth/2fa/verify`, {
+      challenge_token: challengeToken,
+      code
+    }).pipe(
+      tap(response => this.storeSession(response))
+    );
+  }
+
+  // Exchanges the refresh token for a new token pair; concurrent callers share one request
+  refreshToken(): Observable<LoginResponse> {
+    if (!this.refreshInFlight) {
+      this.refreshInFlight = this.http.post<LoginResponse>(`${this.apiUrl}/auth/refresh`, {
+        refresh_token: this.getRefreshToken()
+      }).pipe(
+        tap({
+          next: response => this.storeSession(response),
+          error: () => this.clearSession()
+        }),
+        finalize(() => this.refreshInFlight = null),
+        shareReplay(1)
+      );
+    }
+    return this.refreshInFlight;
+  }
+
+  changePassword(currentPassword: string, newPassword: string): Observable<any> {
+    return this.http.post(`${this.apiUrl}/auth/password`, {
+      current_password: currentPassword,
+      new_password: newPassword
+    }).pipe(
+      tap(() => {
+        const user = this.getCurrentUser();
+        if (user) {
+          user.must_change_password = false;
+          localStorage.setItem(this.userKey, JSON.stringify(user));
+          this.currentUserSubject.next(user);
+        }
+      })
+    );
+  }
+
+  // Byline shown on the author's posts
+  updateProfile(profile: { display_name?: string; avatar_url?: string; bio?: string }): Observable<Admin> {
+    return this.http.put<Admin>(`${this.apiUrl}/auth/profile`, profile).pipe(
+      tap(user => {
+        localStorage.setItem(this.userKey, JSON.stringify(user));
+        this.currentUserSubject.next(user);
+      })
+    );
+  }
+
+  forgotPassword(email: string): Observable<any> {
+    return this.http.post(`${this.apiUrl}/auth/forgot-password`, { email });
+  }
+
+  resetPassword(token: string, newPassword: string): Observable<any> {
+    return this.http.post(`${this.apiUrl}/auth/reset-password`, { token, new_password: newPassword });
+  }
+
   logout(): Observable<any> {
-    return this.http.post(`${this.apiUrl}/auth/logout`, {})
+    return this.http.post(`${this.apiUrl}/auth/logout`, { refresh_token: this.getRefreshToken() })
       .pipe(
-        tap(() => {
-          localStorage.removeItem(this.tokenKey);
-          localStorage.removeItem(this.userKey);
-          this.currentUserSubject.next(null);
-        })
+        finalize(() => this.clearSession())
       );
   }
 
@@ -43,6 +102,10 @@ export class AuthService {
     return localStorage.getItem(this.tokenKey);
   }
 
+  getRefreshToken(): string | null {
+    return localStorage.getItem(this.refreshTokenKey);
+  }
+
   getCurrentUser(): Admin | null {
     const user = localStorage.getItem(this.userKey);
     return user ? JSON.parse(user) : null;
@@ -51,4 +114,18 @@ export class AuthService {
   isAuthenticated(): boolean {
     return !!this.getToken();
   }
+
+  private storeSession(response: LoginResponse): void {
+    localStorage.setItem(this.tokenKey, response.token);
+    localStorage.setItem(this.refreshTokenKey, response.refresh_token);
+    localStorage.setItem(this.userKey, JSON.stringify(response.admin));
+    this.currentUserSubject.next(response.admin);
+  }
+
+  private clearSession(): void {
+    localStorage.removeItem(this.tokenKey);
+    localStorage.removeItem(this.refreshTokenKey);
+    localStorage.removeItem(this.userKey);
+    this.currentUserSubject.next(null);
+  }
 }
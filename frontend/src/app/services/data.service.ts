@@ -1,9 +1,9 @@
 import { HttpClient, HttpHeaders, HttpParams } from '@angular/common/http';
 import { Injectable } from '@angular/core';
-import { Observable } from 'rxjs';
-import { map } from 'rxjs/operators';
+import { EMPTY, Observable } from 'rxjs';
+import { expand, map, reduce } from 'rxjs/operators';
 import { environment } from '../../environments/environment';
-import { Category, CategoryTreeItem, CreateCategoryRequest, GlobalSEOSettings, HomeContent, Post, UpdateCategoryRequest } from '../models/models';
+import { AuthorResponse, BulkPostRequest, BulkPostResponse, Category, CategoryDeleteStrategy, CategoryDeletionPreview, CategoryTreeItem, CreateCategoryRequest, GlobalSEOSettings, HomeContent, MarkdownImportResponse, Post, PostListQuery, PostListResponse, PostRevision, PostRevisionDiff, ScheduledPostChange, SearchResponse, Tag, TagRequest, TrashResponse, UpdateCategoryRequest } from '../models/models';
 import { AuthService } from './auth.service';
 import { FooterContent } from '../pages/admin/admin.component';
 
@@ -11,6 +11,9 @@ import { FooterContent } from '../pages/admin/admin.component';
   providedIn: 'root'
 })
 export class DataService {
+  // The largest page the API serves for post listings
+  private static readonly maxPostPageSize = 100;
+
   private apiUrl: string;
 
   constructor(private http: HttpClient, private authService: AuthService) {
@@ -34,9 +37,18 @@ export class DataService {
 
   // Categories
   getCategories(): Observable<Category[]> {
+    return this.fetchCategories(`${this.apiUrl}/categories`);
+  }
+
+  // All categories including inactive ones, for the admin pages
+  getAdminCategories(): Observable<Category[]> {
+    return this.fetchCategories(`${this.apiUrl}/admin/categories`);
+  }
+
+  private fetchCategories(url: string): Observable<Category[]> {
     // Add cache-busting timestamp to prevent HTTP caching issues
     const cacheBuster = new Date().getTime();
-    return this.http.get<any[]>(`${this.apiUrl}/categories?_t=${cacheBuster}`).pipe(
+    return this.http.get<any[]>(`${url}?_t=${cacheBuster}`).pipe(
       map(apiCategories => {
         return apiCategories.map(apiCategory => ({
           id: apiCategory.id,
@@ -121,12 +133,27 @@ export class DataService {
     return this.http.post<Category>(`${this.apiUrl}/categories`, category);
   }
 
-  updateCategory(id: number, category: UpdateCategoryRequest): Observable<Category> {
-    return this.http.put<Category>(`${this.apiUrl}/categories/${id}`, category);
+  getAdminCategory(id: number): Observable<Category> {
+    return this.http.get<Category>(`${this.apiUrl}/admin/categories/${id}`);
   }
 
-  deleteCategory(id: number): Observable<any> {
-    return this.http.delete(`${this.apiUrl}/categories/${id}`);
+  // version is the one the edit started from; the server answers 412 if it changed since
+  updateCategory(id: number, category: UpdateCategoryRequest, version?: number): Observable<Category> {
+    return this.http.put<Category>(`${this.apiUrl}/categories/${id}`, category, { headers: this.ifMatch(version) });
+  }
+
+  getCategoryDeletionPreview(id: number): Observable<CategoryDeletionPreview> {
+    return this.http.get<CategoryDeletionPreview>(`${this.apiUrl}/admin/categories/${id}/deletion-preview`);
+  }
+
+  // block refuses to delete a category with content, reassign moves it to targetId first,
+  // cascade trashes it along with the category
+  deleteCategory(id: number, strategy: CategoryDeleteStrategy = 'block', targetId?: number): Observable<any> {
+    let params = new HttpParams().set('strategy', strategy);
+    if (targetId !== undefined) {
+      params = params.set('target_id', targetId);
+    }
+    return this.http.delete(`${this.apiUrl}/categories/${id}`, { params });
   }
 
   // Reorder categories
@@ -140,30 +167,173 @@ export class DataService {
   }
 
   // Posts
+  // Every published post, fetched page by page; use getPostPage to show one page at a time
   getPosts(categoryId?: number): Observable<Post[]> {
-    let params = new HttpParams();
-    if (categoryId) {
-      params = params.set('category', categoryId.toString());
-    }
-    return this.http.get<Post[]>(`${this.apiUrl}/posts`, { params });
+    return this.allPosts(query => this.getPostPage(query), { category: categoryId });
+  }
+
+  getPostPage(query: PostListQuery = {}): Observable<PostListResponse> {
+    return this.http.get<PostListResponse>(`${this.apiUrl}/posts`, { params: this.postListParams(query) });
+  }
+
+  search(q: string, options: { category?: number; limit?: number; offset?: number } = {}): Observable<SearchResponse> {
+    return this.http.get<SearchResponse>(`${this.apiUrl}/search`, { params: this.postListParams({ q, ...options }) });
   }
 
   getPost(id: number): Observable<Post> {
     return this.http.get<Post>(`${this.apiUrl}/posts/${id}`);
   }
 
+  getPostBySlug(slug: string): Observable<Post> {
+    return this.http.get<Post>(`${this.apiUrl}/posts/slug/${encodeURIComponent(slug)}`);
+  }
+
+  // Post URLs use the slug, older links still carry the numeric ID
+  getPostByKey(key: string): Observable<Post> {
+    return /^\d+$/.test(key) ? this.getPost(Number(key)) : this.getPostBySlug(key);
+  }
+
+  // Pending scheduled publish/unpublish changes, soonest first
+  getScheduledPosts(limit = 50): Observable<ScheduledPostChange[]> {
+    return this.http.get<ScheduledPostChange[]>(`${this.apiUrl}/admin/posts/scheduled`, { params: { limit } });
+  }
+
+  // Author byline page with a page of their published posts
+  getAuthor(id: number, query: PostListQuery = {}): Observable<AuthorResponse> {
+    return this.http.get<AuthorResponse>(`${this.apiUrl}/authors/${id}`, { params: this.postListParams(query) });
+  }
+
+  // Tags
+  getTags(): Observable<Tag[]> {
+    return this.http.get<Tag[]>(`${this.apiUrl}/tags`);
+  }
+
+  createTag(tag: TagRequest): Observable<Tag> {
+    return this.http.post<Tag>(`${this.apiUrl}/tags`, tag);
+  }
+
+  updateTag(id: number, tag: TagRequest): Observable<Tag> {
+    return this.http.put<Tag>(`${this.apiUrl}/tags/${id}`, tag);
+  }
+
+  deleteTag(id: number): Observable<any> {
+    return this.http.delete(`${this.apiUrl}/tags/${id}`);
+  }
+
+  // Revision history
+  getPostRevisions(postId: number): Observable<PostRevision[]> {
+    return this.http.get<PostRevision[]>(`${this.apiUrl}/admin/posts/${postId}/revisions`);
+  }
+
+  getPostRevision(postId: number, revision: number): Observable<PostRevision> {
+    return this.http.get<PostRevision>(`${this.apiUrl}/admin/posts/${postId}/revisions/${revision}`);
+  }
+
+  // Without from/to the latest revision is compared with the one before it
+  getPostRevisionDiff(postId: number, from?: number, to?: number): Observable<PostRevisionDiff> {
+    const params: Record<string, number> = {};
+    if (from) params['from'] = from;
+    if (to) params['to'] = to;
+    return this.http.get<PostRevisionDiff>(`${this.apiUrl}/admin/posts/${postId}/revisions/diff`, { params });
+  }
+
+  restorePostRevision(postId: number, revision: number, version?: number): Observable<Post> {
+    return this.http.post<Post>(`${this.apiUrl}/posts/${postId}/revisions/${revision}/restore`, {}, { headers: this.ifMatch(version) });
+  }
+
+  // All posts including drafts, for the admin pages
+  getAdminPosts(query: PostListQuery = {}): Observable<Post[]> {
+    return this.allPosts(q => this.getAdminPostPage(q), query);
+  }
+
+  getAdminPostPage(query: PostListQuery = {}): Observable<PostListResponse> {
+    return this.http.get<PostListResponse>(`${this.apiUrl}/admin/posts`, { params: this.postListParams(query) });
+  }
+
+  // Follows next_cursor from page to page and collects the posts of every page
+  private allPosts(fetchPage: (query: PostListQuery) => Observable<PostListResponse>, query: PostListQuery): Observable<Post[]> {
+    const first = { limit: DataService.maxPostPageSize, ...query, offset: undefined, cursor: undefined };
+    return fetchPage(first).pipe(
+      expand(page => page.next_cursor ? fetchPage({ ...first, cursor: page.next_cursor }) : EMPTY),
+      reduce((posts: Post[], page) => posts.concat(page.posts), [])
+    );
+  }
+
+  private postListParams(query: object): HttpParams {
+    let params = new HttpParams();
+    Object.entries(query).forEach(([key, value]) => {
+      if (value !== undefined && value !== null && value !== '') {
+        params = params.set(key, String(value));
+      }
+    });
+    return params;
+  }
+
+  getAdminPost(id: number): Observable<Post> {
+    return this.http.get<Post>(`${this.apiUrl}/admin/posts/${id}`);
+  }
+
   createPost(post: Partial<Post>): Observable<Post> {
     return this.http.post<Post>(`${this.apiUrl}/posts`, post);
   }
 
-  updatePost(id: number, post: Partial<Post>): Observable<Post> {
-    return this.http.put<Post>(`${this.apiUrl}/posts/${id}`, post);
+  updatePost(id: number, post: Partial<Post>, version?: number): Observable<Post> {
+    return this.http.put<Post>(`${this.apiUrl}/posts/${id}`, post, { headers: this.ifMatch(version) });
+  }
+
+  // Only the fields present are changed; null clears a field (JSON Merge Patch)
+  patchPost(id: number, changes: Partial<Post>, version?: number): Observable<Post> {
+    return this.http.patch<Post>(`${this.apiUrl}/posts/${id}`, changes, { headers: this.ifMatch(version) });
+  }
+
+  // If-Match header for an edit based on a version of a post or category. Without a known
+  // version the edit overwrites whatever is current.
+  private ifMatch(version?: number): HttpHeaders {
+    return new HttpHeaders({ 'If-Match': version != null ? `"${version}"` : '*' });
+  }
+
+  // Applies one action to many posts in one transaction; atomic rolls everything back if one post fails
+  bulkPosts(request: BulkPostRequest): Observable<BulkPostResponse> {
+    return this.http.post<BulkPostResponse>(`${this.apiUrl}/posts/bulk`, request);
+  }
+
+  // Markdown files with YAML front matter create or update posts by slug. images are the local
+  // images the files reference, matched by file name; category is used when a file names none.
+  importMarkdownPosts(files: File[], images: File[] = [], category?: string): Observable<MarkdownImportResponse> {
+    const formData = new FormData();
+    files.forEach(file => formData.append('files', file, file.name));
+    images.forEach(image => formData.append('images', image, image.name));
+    if (category) {
+      formData.append('category', category);
+    }
+    return this.http.post<MarkdownImportResponse>(`${this.apiUrl}/posts/import`, formData);
   }
 
   deletePost(id: number): Observable<any> {
     return this.http.delete(`${this.apiUrl}/posts/${id}`);
   }
 
+  // Trash: deleted posts and categories can be restored until they are purged
+  getTrash(): Observable<TrashResponse> {
+    return this.http.get<TrashResponse>(`${this.apiUrl}/admin/trash`);
+  }
+
+  restoreTrashedPost(id: number): Observable<Post> {
+    return this.http.post<Post>(`${this.apiUrl}/trash/posts/${id}/restore`, {});
+  }
+
+  purgeTrashedPost(id: number): Observable<any> {
+    return this.http.delete(`${this.apiUrl}/trash/posts/${id}`);
+  }
+
+  restoreTrashedCategory(id: number): Observable<any> {
+    return this.http.post(`${this.apiUrl}/trash/categories/${id}/restore`, {});
+  }
+
+  purgeTrashedCategory(id: number): Observable<any> {
+    return this.http.delete(`${this.apiUrl}/trash/categories/${id}`);
+  }
+
   incrementPostViews(id: number): Observable<any> {
     return this.http.post(`${this.apiUrl}/posts/${id}/view`, {});
   }
@@ -177,6 +347,10 @@ export class DataService {
     return this.http.put<HomeContent>(`${this.apiUrl}/home-content`, content);
   }
 
+  patchHomeContent(changes: Partial<HomeContent>): Observable<HomeContent> {
+    return this.http.patch<HomeContent>(`${this.apiUrl}/home-content`, changes);
+  }
+
   // Footer Content
   getFooterContent(): Observable<FooterContent> {
     return this.http.get<FooterContent>(`${this.apiUrl}/footer-content`);
@@ -186,6 +360,10 @@ export class DataService {
     return this.http.put<FooterContent>(`${this.apiUrl}/footer-content`, content);
   }
 
+  patchFooterContent(changes: Partial<FooterContent>): Observable<FooterContent> {
+    return this.http.patch<FooterContent>(`${this.apiUrl}/footer-content`, changes);
+  }
+
   // Homepage Media Management
   getHomepageMedia(): Observable<{images: string[], videos: string[]}> {
     return this.http.get<{images: string[], videos: string[]}>(`${this.apiUrl}/homepage/media`);
@@ -271,4 +449,8 @@ export class DataService {
 
     return this.http.put<GlobalSEOSettings>(`${this.apiUrl}/seo-settings`, settings, { headers });
   }
+
+  patchGlobalSEOSettings(changes: Partial<GlobalSEOSettings>): Observable<GlobalSEOSettings> {
+    return this.http.patch<GlobalSEOSettings>(`${this.apiUrl}/seo-settings`, changes);
+  }
 }
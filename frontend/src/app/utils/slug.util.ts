@@ -0,0 +1,28 @@
+/**
+ * Slug preview matching the backend slug package, which has the final say
+ */
+export class SlugUtil {
+
+  private static readonly MAX_LENGTH = 80;
+
+  /**
+   * Convert text to a slug, e.g. "Mẫu Thiết Kế" becomes "mau-thiet-ke"
+   */
+  static generate(text: string): string {
+    const slug = (text || '')
+      .normalize('NFD')
+      .replace(/[\u0300-\u036f]/g, '') // Remove diacritics
+      .replace(/[đĐ]/g, 'd')
+      .toLowerCase()
+      .replace(/['’]/g, '')
+      .replace(/[^a-z0-9]+/g, '-') // Everything else becomes a single hyphen
+      .replace(/^-+|-+$/g, '');
+
+    if (slug.length <= SlugUtil.MAX_LENGTH) {
+      return slug;
+    }
+    const cut = slug.slice(0, SlugUtil.MAX_LENGTH);
+    const lastHyphen = cut.lastIndexOf('-');
+    return (lastHyphen > SlugUtil.MAX_LENGTH / 2 ? cut.slice(0, lastHyphen) : cut).replace(/-+$/, '');
+  }
+}